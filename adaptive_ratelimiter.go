@@ -0,0 +1,140 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitObserver is implemented by rate limiters which adjust themselves to the
+// x-ratelimit-* headers returned by the API. The Client reports the headers of
+// every response of a rate limited request to the observer.
+type RateLimitObserver interface {
+	ObserveRateLimits(model string, headers RateLimitHeaders)
+}
+
+// AdaptiveRateLimiter is a MemRateLimiter which resizes its per-model buckets to the
+// limits reported by the API and pauses a model until its limit resets once the
+// remaining requests or tokens reach zero. The hardcoded per-model limits are only
+// used until the first response for the model is observed.
+type AdaptiveRateLimiter struct {
+	*MemRateLimiter
+
+	pauseMutex  sync.Mutex
+	pausedUntil map[string]time.Time
+}
+
+func NewAdaptiveRateLimiter(apiType APIType) *AdaptiveRateLimiter {
+	return &AdaptiveRateLimiter{
+		MemRateLimiter: NewMemRateLimiter(apiType),
+		pausedUntil:    make(map[string]time.Time),
+	}
+}
+
+func (r *AdaptiveRateLimiter) WaitForRequest(ctx context.Context, model string, req TokenCountable) error {
+	return waitForRequest(ctx, model, req, r.Wait)
+}
+
+func (r *AdaptiveRateLimiter) Wait(ctx context.Context, model string, tokens int) error {
+	r.pauseMutex.Lock()
+	until := r.pausedUntil[model]
+	r.pauseMutex.Unlock()
+
+	err := sleepContext(ctx, time.Until(until))
+	if err != nil {
+		return err
+	}
+
+	return r.MemRateLimiter.Wait(ctx, model, tokens)
+}
+
+// ObserveRateLimits implements RateLimitObserver.
+func (r *AdaptiveRateLimiter) ObserveRateLimits(model string, headers RateLimitHeaders) {
+	if headers.LimitRequests > 0 {
+		r.resize(r.RequestLimiters, model, headers.LimitRequests)
+		if headers.RemainingRequests == 0 {
+			r.pause(model, headers.ResetRequests)
+		}
+	}
+
+	if headers.LimitTokens > 0 {
+		r.resize(r.TokensLimiters, model, headers.LimitTokens)
+		if headers.RemainingTokens == 0 {
+			r.pause(model, headers.ResetTokens)
+		}
+	}
+}
+
+// resize sets the limiter of the model to the given per minute limit.
+func (r *AdaptiveRateLimiter) resize(limiters map[string]*rate.Limiter, model string, minuteRate int) {
+	if limiters == nil {
+		return
+	}
+
+	limit := rate.Limit(float64(minuteRate) / SecondsPerMinute)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	limiter, ok := limiters[model]
+	if !ok {
+		limiters[model] = rate.NewLimiter(limit, minuteRate)
+		return
+	}
+
+	// if limiter is nil, it means that the model is not rate limited
+	if limiter == nil {
+		return
+	}
+
+	if limiter.Limit() != limit {
+		limiter.SetLimit(limit)
+	}
+	if limiter.Burst() != minuteRate {
+		limiter.SetBurst(minuteRate)
+	}
+}
+
+func (r *AdaptiveRateLimiter) pause(model string, reset ResetTime) {
+	d, ok := reset.Duration()
+	if !ok || d <= 0 {
+		return
+	}
+
+	until := time.Now().Add(d)
+
+	r.pauseMutex.Lock()
+	defer r.pauseMutex.Unlock()
+
+	if until.After(r.pausedUntil[model]) {
+		r.pausedUntil[model] = until
+	}
+}
+
+type rateLimitModelKey struct{}
+
+// withRateLimitModel marks the request as rate limited under the given model,
+// so that the rate limit headers of its responses are reported to the rate limiter.
+func withRateLimitModel(model string) requestOption {
+	return func(args *requestOptions) {
+		args.rateLimitModel = model
+	}
+}
+
+// observeRateLimits reports the rate limit headers of resp to the rate limiter.
+func (c *Client) observeRateLimits(req *http.Request, resp *http.Response) {
+	observer, ok := c.rateLimiter.(RateLimitObserver)
+	if !ok || resp == nil {
+		return
+	}
+
+	model, _ := req.Context().Value(rateLimitModelKey{}).(string)
+	if model == "" {
+		return
+	}
+
+	observer.ObserveRateLimits(model, newRateLimitHeaders(resp.Header))
+}
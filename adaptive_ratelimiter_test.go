@@ -0,0 +1,132 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	. "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func TestAdaptiveRateLimiterResizesBuckets(t *testing.T) {
+	r := NewAdaptiveRateLimiter(APITypeOpenAI)
+	r.ObserveRateLimits(GPT4, RateLimitHeaders{
+		LimitRequests:     500,
+		LimitTokens:       30000,
+		RemainingRequests: 499,
+		RemainingTokens:   29000,
+		ResetRequests:     "120ms",
+		ResetTokens:       "2s",
+	})
+
+	requests := r.RequestLimiters[GPT4]
+	if requests.Burst() != 500 || requests.Limit() != rate.Limit(500.0/60) {
+		t.Fatalf("unexpected request limiter: burst %d, limit %v", requests.Burst(), requests.Limit())
+	}
+
+	tokens := r.TokensLimiters[GPT4]
+	if tokens.Burst() != 30000 || tokens.Limit() != rate.Limit(30000.0/60) {
+		t.Fatalf("unexpected tokens limiter: burst %d, limit %v", tokens.Burst(), tokens.Limit())
+	}
+
+	r.ObserveRateLimits("custom-model", RateLimitHeaders{LimitRequests: 30, RemainingRequests: 29})
+	if r.RequestLimiters["custom-model"].Burst() != 30 {
+		t.Fatalf("limiter wasn't created for an unknown model")
+	}
+}
+
+func TestAdaptiveRateLimiterIgnoresMissingHeaders(t *testing.T) {
+	r := NewAdaptiveRateLimiter(APITypeOpenAI)
+	before := r.RequestLimiters[GPT4].Burst()
+	r.ObserveRateLimits(GPT4, RateLimitHeaders{})
+
+	if r.RequestLimiters[GPT4].Burst() != before {
+		t.Fatalf("limiter was resized without rate limit headers")
+	}
+
+	start := time.Now()
+	err := r.Wait(context.Background(), GPT4, 0)
+	checks.NoError(t, err, "Wait error")
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatalf("Wait() paused without rate limit headers")
+	}
+}
+
+func TestAdaptiveRateLimiterPausesUntilReset(t *testing.T) {
+	r := NewAdaptiveRateLimiter(APITypeOpenAI)
+	r.ObserveRateLimits(GPT4, RateLimitHeaders{
+		LimitRequests:     500,
+		LimitTokens:       30000,
+		RemainingRequests: 0,
+		RemainingTokens:   20000,
+		ResetRequests:     "100ms",
+		ResetTokens:       "1s",
+	})
+
+	start := time.Now()
+	err := r.Wait(context.Background(), GPT4, 10)
+	checks.NoError(t, err, "Wait error")
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Wait() cost time = %v, want at least 100ms", elapsed)
+	}
+
+	err = r.Wait(context.Background(), GPT3Dot5Turbo, 10)
+	checks.NoError(t, err, "Wait error")
+
+	r.ObserveRateLimits(GPT4, RateLimitHeaders{
+		LimitTokens:     30000,
+		RemainingTokens: 0,
+		ResetTokens:     "1m0s",
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = r.Wait(ctx, GPT4, 10)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientFeedsRateLimitHeadersToLimiter(t *testing.T) {
+	server := test.NewTestServer()
+	calls := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("x-ratelimit-limit-requests", "500")
+		w.Header().Set("x-ratelimit-remaining-requests", "0")
+		w.Header().Set("x-ratelimit-reset-requests", "150ms")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","choices":[]}`)
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	config := DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	config.EnableRateLimiter = true
+	client := NewClientWithConfig(config)
+
+	req := ChatCompletionRequest{
+		MaxTokens: 5,
+		Model:     GPT3Dot5Turbo,
+		Messages:  []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	_, err := client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	start := time.Now()
+	_, err = client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("second request wasn't paused until the limit reset: %v", elapsed)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
@@ -111,12 +111,12 @@ func (c *Client) callAudioAPI(
 
 	urlSuffix := fmt.Sprintf("/audio/%s", endpointSuffix)
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model),
-		withBody(&formBody), withContentType(builder.FormDataContentType()))
+		withBody(&formBody), withContentType(builder.FormDataContentType()), withRateLimitModel(request.Model))
 	if err != nil {
 		return AudioResponse{}, err
 	}
 
-	if c.config.EnableRateLimiter {
+	if c.rateLimiter != nil {
 		err = c.rateLimiter.WaitForRequest(ctx, request.Model, request)
 		if err != nil {
 			return
@@ -0,0 +1,396 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	batchesSuffix = "/batches"
+	// defaultBatchPollInterval is the interval of WaitForBatch when none is given.
+	defaultBatchPollInterval = 10 * time.Second
+)
+
+var (
+	ErrBatchDuplicateCustomID = errors.New("duplicate custom_id in batch")
+	ErrBatchMixedEndpoints    = errors.New("batch requests must target the same URL")
+	ErrBatchEmpty             = errors.New("batch has no requests")
+)
+
+// BatchEndpoint is the endpoint of the requests of a batch.
+type BatchEndpoint string
+
+const (
+	BatchEndpointChatCompletions BatchEndpoint = "/v1/chat/completions"
+	BatchEndpointCompletions     BatchEndpoint = "/v1/completions"
+	BatchEndpointEmbeddings      BatchEndpoint = "/v1/embeddings"
+)
+
+// BatchStatus is the status of a batch.
+type BatchStatus string
+
+const (
+	BatchStatusValidating BatchStatus = "validating"
+	BatchStatusFailed     BatchStatus = "failed"
+	BatchStatusInProgress BatchStatus = "in_progress"
+	BatchStatusFinalizing BatchStatus = "finalizing"
+	BatchStatusCompleted  BatchStatus = "completed"
+	BatchStatusExpired    BatchStatus = "expired"
+	BatchStatusCancelling BatchStatus = "cancelling"
+	BatchStatusCancelled  BatchStatus = "cancelled"
+)
+
+// Done reports whether the batch reached a final status.
+func (s BatchStatus) Done() bool {
+	switch s {
+	case BatchStatusFailed, BatchStatusCompleted, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// BatchRequestLine is a line of the JSONL input file of a batch.
+type BatchRequestLine struct {
+	// CustomID identifies the request in the results of the batch, it must be unique in the batch.
+	CustomID string        `json:"custom_id"`
+	Method   string        `json:"method"`
+	URL      BatchEndpoint `json:"url"`
+	Body     any           `json:"body"`
+}
+
+// NewBatchChatCompletionLine returns the line of a chat completion request.
+func NewBatchChatCompletionLine(customID string, request ChatCompletionRequest) BatchRequestLine {
+	return BatchRequestLine{
+		CustomID: customID,
+		Method:   http.MethodPost,
+		URL:      BatchEndpointChatCompletions,
+		Body:     request,
+	}
+}
+
+// NewBatchEmbeddingLine returns the line of an embeddings request.
+func NewBatchEmbeddingLine(customID string, request EmbeddingRequestConverter) BatchRequestLine {
+	return BatchRequestLine{
+		CustomID: customID,
+		Method:   http.MethodPost,
+		URL:      BatchEndpointEmbeddings,
+		Body:     request.Convert(),
+	}
+}
+
+// MarshalBatchInput encodes the lines as the JSONL input file of a batch. All the lines must
+// target the same endpoint and have unique custom IDs.
+func MarshalBatchInput(lines []BatchRequestLine) ([]byte, error) {
+	if len(lines) == 0 {
+		return nil, ErrBatchEmpty
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	customIDs := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		if line.URL != lines[0].URL {
+			return nil, fmt.Errorf("%w: %s and %s", ErrBatchMixedEndpoints, lines[0].URL, line.URL)
+		}
+		if _, ok := customIDs[line.CustomID]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrBatchDuplicateCustomID, line.CustomID)
+		}
+		customIDs[line.CustomID] = struct{}{}
+
+		// the encoder terminates every line with a newline
+		if err := encoder.Encode(line); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UploadBatchFile uploads the lines as the input file of a batch.
+func (c *Client) UploadBatchFile(ctx context.Context, name string, lines []BatchRequestLine) (file File, err error) {
+	data, err := MarshalBatchInput(lines)
+	if err != nil {
+		return
+	}
+
+	return c.CreateFileBytes(ctx, FileBytesRequest{
+		Name:    name,
+		Bytes:   data,
+		Purpose: PurposeBatch,
+	})
+}
+
+// Batch is a batch of requests processed asynchronously.
+type Batch struct {
+	ID               string             `json:"id"`
+	Object           string             `json:"object"`
+	Endpoint         BatchEndpoint      `json:"endpoint"`
+	Errors           *BatchErrors       `json:"errors"`
+	InputFileID      string             `json:"input_file_id"`
+	CompletionWindow string             `json:"completion_window"`
+	Status           BatchStatus        `json:"status"`
+	OutputFileID     *string            `json:"output_file_id"`
+	ErrorFileID      *string            `json:"error_file_id"`
+	CreatedAt        int64              `json:"created_at"`
+	InProgressAt     *int64             `json:"in_progress_at"`
+	ExpiresAt        *int64             `json:"expires_at"`
+	FinalizingAt     *int64             `json:"finalizing_at"`
+	CompletedAt      *int64             `json:"completed_at"`
+	FailedAt         *int64             `json:"failed_at"`
+	ExpiredAt        *int64             `json:"expired_at"`
+	CancellingAt     *int64             `json:"cancelling_at"`
+	CancelledAt      *int64             `json:"cancelled_at"`
+	RequestCounts    BatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]string  `json:"metadata"`
+
+	httpHeader
+}
+
+// BatchErrors are the errors of the validation of the input file of a batch.
+type BatchErrors struct {
+	Object string       `json:"object"`
+	Data   []BatchError `json:"data"`
+}
+
+type BatchError struct {
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	Param   *string `json:"param"`
+	Line    *int    `json:"line"`
+}
+
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// CreateBatchRequest is the request to create a batch from an uploaded input file.
+type CreateBatchRequest struct {
+	InputFileID string        `json:"input_file_id"`
+	Endpoint    BatchEndpoint `json:"endpoint"`
+	// CompletionWindow is the time frame of the batch, "24h" when empty.
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+// BatchList is a page of batches.
+type BatchList struct {
+	Object  string  `json:"object"`
+	Data    []Batch `json:"data"`
+	FirstID *string `json:"first_id"`
+	LastID  *string `json:"last_id"`
+	HasMore bool    `json:"has_more"`
+
+	httpHeader
+}
+
+// CreateBatch creates a batch.
+func (c *Client) CreateBatch(ctx context.Context, request CreateBatchRequest) (response Batch, err error) {
+	if request.CompletionWindow == "" {
+		request.CompletionWindow = "24h"
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(batchesSuffix), withBody(request))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// RetrieveBatch retrieves a batch.
+func (c *Client) RetrieveBatch(ctx context.Context, batchID string) (response Batch, err error) {
+	urlSuffix := fmt.Sprintf("%s/%s", batchesSuffix, batchID)
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// CancelBatch cancels a batch. The batch is cancelling until the requests in progress finish.
+func (c *Client) CancelBatch(ctx context.Context, batchID string) (response Batch, err error) {
+	urlSuffix := fmt.Sprintf("%s/%s/cancel", batchesSuffix, batchID)
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// ListBatches lists the batches of the organization. The next page starts after the LastID of
+// the previous page.
+func (c *Client) ListBatches(ctx context.Context, after *string, limit *int) (response BatchList, err error) {
+	urlValues := url.Values{}
+	if after != nil {
+		urlValues.Add("after", *after)
+	}
+	if limit != nil {
+		urlValues.Add("limit", fmt.Sprintf("%d", *limit))
+	}
+
+	encodedValues := ""
+	if len(urlValues) > 0 {
+		encodedValues = "?" + urlValues.Encode()
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(batchesSuffix+encodedValues))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// WaitForBatch polls the batch at the interval until it reaches a final status or the context is done.
+// A zero or negative interval polls every 10 seconds.
+func (c *Client) WaitForBatch(ctx context.Context, batchID string, interval time.Duration) (Batch, error) {
+	if interval <= 0 {
+		interval = defaultBatchPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		batch, err := c.RetrieveBatch(ctx, batchID)
+		if err != nil || batch.Status.Done() {
+			return batch, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return batch, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// BatchResponseLine is a line of the output or error file of a batch.
+type BatchResponseLine struct {
+	ID       string         `json:"id"`
+	CustomID string         `json:"custom_id"`
+	Response *BatchResponse `json:"response"`
+	Error    *APIError      `json:"error"`
+}
+
+// BatchResponse is the response to a request of a batch.
+type BatchResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestID  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// Err returns the error of the line: the error of the request when it couldn't be sent, or
+// the APIError of its response.
+func (l *BatchResponseLine) Err() error {
+	if l.Error != nil {
+		return l.Error
+	}
+	if l.Response == nil {
+		return fmt.Errorf("batch request %q has no response", l.CustomID)
+	}
+	if l.Response.StatusCode < http.StatusOK || l.Response.StatusCode >= http.StatusBadRequest {
+		var errRes ErrorResponse
+		err := json.Unmarshal(l.Response.Body, &errRes)
+		if err != nil || errRes.Error == nil {
+			return &RequestError{
+				HTTPStatusCode: l.Response.StatusCode,
+				Err:            fmt.Errorf("batch request %q failed: %s", l.CustomID, l.Response.Body),
+				Metadata:       ResponseMetadata{RequestID: l.Response.RequestID},
+			}
+		}
+		errRes.Error.HTTPStatusCode = l.Response.StatusCode
+		errRes.Error.Metadata = ResponseMetadata{RequestID: l.Response.RequestID}
+		return errRes.Error
+	}
+	return nil
+}
+
+// BatchResultsReader reads the lines of the output or error file of a batch one by one.
+type BatchResultsReader struct {
+	decoder *json.Decoder
+	closer  io.Closer
+}
+
+// NewBatchResultsReader returns a reader of the JSONL results of a batch.
+func NewBatchResultsReader(r io.Reader) *BatchResultsReader {
+	reader := &BatchResultsReader{decoder: json.NewDecoder(r)}
+	if closer, ok := r.(io.Closer); ok {
+		reader.closer = closer
+	}
+	return reader
+}
+
+// GetBatchResults streams the content of the output or error file of a batch.
+func (c *Client) GetBatchResults(ctx context.Context, fileID string) (*BatchResultsReader, error) {
+	content, err := c.GetFileContent(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatchResultsReader(content), nil
+}
+
+// Next returns the next line of the results, or io.EOF after the last line.
+func (r *BatchResultsReader) Next() (line BatchResponseLine, err error) {
+	err = r.decoder.Decode(&line)
+	return
+}
+
+// Close closes the underlying file content.
+func (r *BatchResultsReader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// BatchResults are the typed responses and the errors of the requests of a batch, by custom ID.
+type BatchResults[T any] struct {
+	Responses map[string]T
+	Errors    map[string]error
+}
+
+// ReadBatchResults reads the lines of the readers and decodes the bodies of the successful
+// responses as T, e.g. ChatCompletionResponse or EmbeddingResponse. Both the output and the
+// error files of a batch can be read into the same results.
+func ReadBatchResults[T any](readers ...*BatchResultsReader) (BatchResults[T], error) {
+	results := BatchResults[T]{
+		Responses: make(map[string]T),
+		Errors:    make(map[string]error),
+	}
+	for _, reader := range readers {
+		for {
+			line, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return results, fmt.Errorf("failed to read batch results: %w", err)
+			}
+
+			if err = line.Err(); err != nil {
+				results.Errors[line.CustomID] = err
+				continue
+			}
+			var response T
+			if err = json.Unmarshal(line.Response.Body, &response); err != nil {
+				results.Errors[line.CustomID] = fmt.Errorf("failed to decode batch response: %w", err)
+				continue
+			}
+			results.Responses[line.CustomID] = response
+		}
+	}
+	return results, nil
+}
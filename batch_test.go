@@ -0,0 +1,184 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func TestMarshalBatchInput(t *testing.T) {
+	chat := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	data, err := openai.MarshalBatchInput([]openai.BatchRequestLine{
+		openai.NewBatchChatCompletionLine("request-1", chat),
+		openai.NewBatchChatCompletionLine("request-2", chat),
+	})
+	checks.NoError(t, err, "MarshalBatchInput error")
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("MarshalBatchInput() returned %d lines, want 2", len(lines))
+	}
+	var line struct {
+		CustomID string                       `json:"custom_id"`
+		Method   string                       `json:"method"`
+		URL      string                       `json:"url"`
+		Body     openai.ChatCompletionRequest `json:"body"`
+	}
+	err = json.Unmarshal([]byte(lines[1]), &line)
+	checks.NoError(t, err, "Unmarshal error")
+	if line.CustomID != "request-2" || line.Method != http.MethodPost || line.URL != "/v1/chat/completions" ||
+		line.Body.Messages[0].Content != "Hello!" {
+		t.Fatalf("unexpected line: %s", lines[1])
+	}
+
+	embedding := openai.NewBatchEmbeddingLine("request-1", openai.EmbeddingRequestStrings{Input: []string{"Hello"}})
+	_, err = openai.MarshalBatchInput([]openai.BatchRequestLine{
+		openai.NewBatchChatCompletionLine("request-0", chat),
+		embedding,
+	})
+	checks.ErrorIs(t, err, openai.ErrBatchMixedEndpoints, "mixed endpoints should be rejected")
+	_, err = openai.MarshalBatchInput([]openai.BatchRequestLine{embedding, embedding})
+	checks.ErrorIs(t, err, openai.ErrBatchDuplicateCustomID, "duplicate custom IDs should be rejected")
+	_, err = openai.MarshalBatchInput(nil)
+	checks.ErrorIs(t, err, openai.ErrBatchEmpty, "empty batches should be rejected")
+}
+
+func TestBatches(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		checks.NoError(t, r.ParseMultipartForm(1<<20), "ParseMultipartForm error")
+		if purpose := r.FormValue("purpose"); purpose != "batch" {
+			t.Fatalf("unexpected purpose %q", purpose)
+		}
+		fmt.Fprint(w, `{"id":"file-input","purpose":"batch"}`)
+	})
+	server.RegisterHandler("/v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.URL.Query().Get("after") != "batch-0" || r.URL.Query().Get("limit") != "1" {
+				t.Fatalf("unexpected query %q", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"object":"list","data":[{"id":"batch-1"}],"first_id":"batch-1","last_id":"batch-1","has_more":true}`)
+			return
+		}
+
+		var request openai.CreateBatchRequest
+		checks.NoError(t, json.NewDecoder(r.Body).Decode(&request), "Decode error")
+		if request.InputFileID != "file-input" || request.CompletionWindow != "24h" {
+			t.Fatalf("unexpected request %+v", request)
+		}
+		fmt.Fprint(w, `{"id":"batch-1","status":"validating","endpoint":"/v1/chat/completions"}`)
+	})
+	retrievals := 0
+	server.RegisterHandler("/v1/batches/batch-1", func(w http.ResponseWriter, _ *http.Request) {
+		retrievals++
+		status := "in_progress"
+		if retrievals > 1 {
+			status = "completed"
+		}
+		fmt.Fprintf(w, `{"id":"batch-1","status":%q,"output_file_id":"file-output","request_counts":{"total":2}}`, status)
+	})
+	server.RegisterHandler("/v1/batches/batch-1/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Fatalf("unexpected method %s", r.Method)
+		}
+		fmt.Fprint(w, `{"id":"batch-1","status":"cancelling"}`)
+	})
+
+	ctx := context.Background()
+	file, err := client.UploadBatchFile(ctx, "input.jsonl", []openai.BatchRequestLine{
+		openai.NewBatchChatCompletionLine("request-1", openai.ChatCompletionRequest{Model: openai.GPT3Dot5Turbo}),
+	})
+	checks.NoError(t, err, "UploadBatchFile error")
+
+	batch, err := client.CreateBatch(ctx, openai.CreateBatchRequest{
+		InputFileID: file.ID,
+		Endpoint:    openai.BatchEndpointChatCompletions,
+	})
+	checks.NoError(t, err, "CreateBatch error")
+	if batch.ID != "batch-1" || batch.Status != openai.BatchStatusValidating || batch.Status.Done() {
+		t.Fatalf("unexpected batch %+v", batch)
+	}
+
+	batch, err = client.WaitForBatch(ctx, batch.ID, time.Millisecond)
+	checks.NoError(t, err, "WaitForBatch error")
+	if batch.Status != openai.BatchStatusCompleted || *batch.OutputFileID != "file-output" || retrievals != 2 {
+		t.Fatalf("unexpected batch %+v after %d retrievals", batch, retrievals)
+	}
+
+	// the interval defaults when it isn't positive
+	for _, interval := range []time.Duration{0, -time.Second} {
+		batch, err = client.WaitForBatch(ctx, batch.ID, interval)
+		checks.NoError(t, err, "WaitForBatch error")
+		if batch.Status != openai.BatchStatusCompleted {
+			t.Fatalf("unexpected batch %+v", batch)
+		}
+	}
+
+	batch, err = client.CancelBatch(ctx, batch.ID)
+	checks.NoError(t, err, "CancelBatch error")
+	if batch.Status != openai.BatchStatusCancelling {
+		t.Fatalf("unexpected batch %+v", batch)
+	}
+
+	after, limit := "batch-0", 1
+	batches, err := client.ListBatches(ctx, &after, &limit)
+	checks.NoError(t, err, "ListBatches error")
+	if len(batches.Data) != 1 || *batches.LastID != "batch-1" || !batches.HasMore {
+		t.Fatalf("unexpected batches %+v", batches)
+	}
+}
+
+func TestBatchResults(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	//nolint:lll
+	output := `{"id":"1","custom_id":"request-1","response":{"status_code":200,"request_id":"req-1","body":{"id":"chatcmpl-1","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"}}]}},"error":null}
+{"id":"2","custom_id":"request-2","response":{"status_code":429,"request_id":"req-2","body":{"error":{"message":"Rate limit reached","type":"requests"}}},"error":null}
+`
+	server.RegisterHandler("/v1/files/file-output/content", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, output)
+	})
+	errorOutput := `{"id":"3","custom_id":"request-3","error":{"code":"batch_expired","message":"expired"}}`
+
+	reader, err := client.GetBatchResults(context.Background(), "file-output")
+	checks.NoError(t, err, "GetBatchResults error")
+	defer reader.Close()
+	results, err := openai.ReadBatchResults[openai.ChatCompletionResponse](reader,
+		openai.NewBatchResultsReader(strings.NewReader(errorOutput)))
+	checks.NoError(t, err, "ReadBatchResults error")
+
+	if len(results.Responses) != 1 || results.Responses["request-1"].Choices[0].Message.Content != "Hello!" {
+		t.Fatalf("unexpected responses %+v", results.Responses)
+	}
+	if len(results.Errors) != 2 {
+		t.Fatalf("unexpected errors %+v", results.Errors)
+	}
+	checks.ErrorIs(t, results.Errors["request-2"], openai.ErrRateLimited, "the API errors should be classified")
+	metadata, ok := openai.ErrorMetadata(results.Errors["request-2"])
+	if !ok || metadata.RequestID != "req-2" {
+		t.Fatalf("ErrorMetadata() = %+v, %t, want the request ID of the response", metadata, ok)
+	}
+	var apiErr *openai.APIError
+	if !errors.As(results.Errors["request-3"], &apiErr) || apiErr.Code != "batch_expired" {
+		t.Fatalf("unexpected error %v", results.Errors["request-3"])
+	}
+
+	_, err = openai.ReadBatchResults[openai.ChatCompletionResponse](
+		openai.NewBatchResultsReader(strings.NewReader(`{"id":`)))
+	if err == nil || errors.Is(err, io.EOF) {
+		t.Fatalf("ReadBatchResults() error = %v, want a decoding error", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 )
 
@@ -217,6 +216,15 @@ type ChatCompletionRequest struct {
 	Tools        []Tool `json:"tools,omitempty"`
 	// This can be either a string or an ToolChoice object.
 	ToolChoice any `json:"tool_choice,omitempty"`
+	// StreamOptions configures streamed completions, it's only valid when streaming.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions are the options of streamed completions.
+type StreamOptions struct {
+	// IncludeUsage requests a final chunk with the usage of the whole request, and without choices.
+	// The usage of the other chunks is null.
+	IncludeUsage bool `json:"include_usage,omitempty"`
 }
 
 type ToolType string
@@ -286,19 +294,12 @@ const (
 	FinishReasonNull          FinishReason = "null"
 )
 
+// Tokens returns the number of prompt tokens of the request, including the overhead of
+// messages, names, tool calls, function definitions, images and the reply priming.
+// Images which aren't data URLs are counted as 768x768 images, use ChatTokenCounter to
+// provide their sizes.
 func (c ChatCompletionRequest) Tokens() (tokens int, err error) {
-	var text string
-	for _, message := range c.Messages {
-		text += message.Content
-	}
-
-	ids, _, err := Tokenize(c.Model, text)
-	if err != nil {
-		err = fmt.Errorf("failed to tokenize prompt: %w", err)
-		return
-	}
-
-	return len(ids), nil
+	return ChatTokenCounter{}.RequestTokens(c)
 }
 
 func (r FinishReason) MarshalJSON() ([]byte, error) {
@@ -351,12 +352,13 @@ func (c *Client) CreateChatCompletion(
 		return
 	}
 
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model),
+		withBody(request), withRateLimitModel(request.Model))
 	if err != nil {
 		return
 	}
 
-	if c.config.EnableRateLimiter {
+	if c.rateLimiter != nil {
 		err = c.rateLimiter.WaitForRequest(ctx, request.Model, request)
 		if err != nil {
 			return
@@ -26,21 +26,26 @@ type ChatCompletionStreamResponse struct {
 	Model             string                       `json:"model"`
 	Choices           []ChatCompletionStreamChoice `json:"choices"`
 	PromptAnnotations []PromptAnnotation           `json:"prompt_annotations,omitempty"`
+	SystemFingerprint string                       `json:"system_fingerprint,omitempty"`
+	// Usage is only set in the last chunk of streams with StreamOptions.IncludeUsage.
+	Usage *Usage `json:"usage,omitempty"`
+	// Resumed is true for the first chunk received after a ResumableChatCompletionStream resumed.
+	Resumed bool `json:"-"`
 }
 
-// ChatCompletionStream
-// Note: Perhaps it is more elegant to abstract Stream using generics.
+// ChatCompletionStream is a stream of chat completion chunks.
 type ChatCompletionStream struct {
-	*streamReader[ChatCompletionStreamResponse]
+	*Stream[ChatCompletionStreamResponse]
 }
 
 // CreateChatCompletionStream — API call to create a chat completion w/ streaming
 // support. It sets whether to stream back partial progress. If set, tokens will be
 // sent as data-only server-sent events as they become available, with the
-// stream terminated by a data: [DONE] message.
+// stream terminated by a data: [DONE] message. The options configure the timeouts of the stream.
 func (c *Client) CreateChatCompletionStream(
 	ctx context.Context,
 	request ChatCompletionRequest,
+	opts ...StreamOption,
 ) (stream *ChatCompletionStream, err error) {
 	urlSuffix := chatCompletionsSuffix
 	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
@@ -48,7 +53,7 @@ func (c *Client) CreateChatCompletionStream(
 		return
 	}
 
-	if c.config.EnableRateLimiter {
+	if c.rateLimiter != nil {
 		err = c.rateLimiter.WaitForRequest(ctx, request.Model, request)
 		if err != nil {
 			return
@@ -56,17 +61,18 @@ func (c *Client) CreateChatCompletionStream(
 	}
 
 	request.Stream = true
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model),
+		withBody(request), withRateLimitModel(request.Model))
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := sendRequestStream[ChatCompletionStreamResponse](c, req)
+	resp, err := sendRequestStream[ChatCompletionStreamResponse](c, req, opts...)
 	if err != nil {
 		return
 	}
 	stream = &ChatCompletionStream{
-		streamReader: resp,
+		Stream: resp,
 	}
 	return
 }
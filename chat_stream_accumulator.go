@@ -0,0 +1,177 @@
+package openai
+
+import (
+	"sort"
+)
+
+// ChatCompletionStreamAccumulator reassembles the chunks of a chat completion stream into
+// a ChatCompletionResponse. It merges the deltas of every choice, including the fragments
+// of function and tool calls, so that streamed and non-streamed completions can be
+// handled by the same code.
+type ChatCompletionStreamAccumulator struct {
+	response ChatCompletionResponse
+	// choices maps the indexes of the choices to their position in response.Choices.
+	choices map[int]int
+	// toolCalls maps the indexes of the tool calls of each choice to their position in Message.ToolCalls.
+	toolCalls map[int]map[int]int
+}
+
+func NewChatCompletionStreamAccumulator() *ChatCompletionStreamAccumulator {
+	return &ChatCompletionStreamAccumulator{
+		choices:   make(map[int]int),
+		toolCalls: make(map[int]map[int]int),
+	}
+}
+
+// AddChunk merges a chunk of the stream into the response.
+func (a *ChatCompletionStreamAccumulator) AddChunk(chunk ChatCompletionStreamResponse) {
+	if chunk.ID != "" {
+		a.response.ID = chunk.ID
+	}
+	if chunk.Created != 0 {
+		a.response.Created = chunk.Created
+	}
+	if chunk.Model != "" {
+		a.response.Model = chunk.Model
+	}
+	if chunk.SystemFingerprint != "" {
+		a.response.SystemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		a.response.Usage = *chunk.Usage
+	}
+
+	for _, choice := range chunk.Choices {
+		a.addChoice(choice)
+	}
+}
+
+func (a *ChatCompletionStreamAccumulator) addChoice(chunk ChatCompletionStreamChoice) {
+	position, ok := a.choices[chunk.Index]
+	if !ok {
+		position = len(a.response.Choices)
+		a.choices[chunk.Index] = position
+		a.toolCalls[chunk.Index] = make(map[int]int)
+		a.response.Choices = append(a.response.Choices, ChatCompletionChoice{Index: chunk.Index})
+	}
+	choice := &a.response.Choices[position]
+
+	delta := chunk.Delta
+	if delta.Role != "" {
+		choice.Message.Role = delta.Role
+	}
+	choice.Message.Content += delta.Content
+
+	if delta.FunctionCall != nil {
+		if choice.Message.FunctionCall == nil {
+			choice.Message.FunctionCall = &FunctionCall{}
+		}
+		choice.Message.FunctionCall.Name += delta.FunctionCall.Name
+		choice.Message.FunctionCall.Arguments += delta.FunctionCall.Arguments
+	}
+
+	for _, toolCall := range delta.ToolCalls {
+		a.addToolCall(chunk.Index, &choice.Message, toolCall)
+	}
+
+	if chunk.FinishReason != "" {
+		choice.FinishReason = chunk.FinishReason
+	}
+}
+
+func (a *ChatCompletionStreamAccumulator) addToolCall(choiceIndex int, message *ChatCompletionMessage, chunk ToolCall) {
+	// chunks without an index continue the last tool call, unless they start a new one with an ID
+	index := len(message.ToolCalls) - 1
+	if chunk.Index != nil {
+		index = *chunk.Index
+	} else if chunk.ID != "" || index < 0 {
+		index = len(message.ToolCalls)
+	}
+
+	positions := a.toolCalls[choiceIndex]
+	position, ok := positions[index]
+	if !ok {
+		position = len(message.ToolCalls)
+		positions[index] = position
+		message.ToolCalls = append(message.ToolCalls, ToolCall{})
+	}
+	toolCall := &message.ToolCalls[position]
+
+	if chunk.ID != "" {
+		toolCall.ID = chunk.ID
+	}
+	if chunk.Type != "" {
+		toolCall.Type = chunk.Type
+	}
+	toolCall.Function.Name += chunk.Function.Name
+	toolCall.Function.Arguments += chunk.Function.Arguments
+}
+
+// Response returns the response accumulated so far. Choices and tool calls are ordered by their indexes.
+// The returned response doesn't share memory with the accumulator.
+func (a *ChatCompletionStreamAccumulator) Response() ChatCompletionResponse {
+	response := a.response
+	response.Object = "chat.completion"
+
+	response.Choices = make([]ChatCompletionChoice, len(a.response.Choices))
+	copy(response.Choices, a.response.Choices)
+	sort.SliceStable(response.Choices, func(i, j int) bool {
+		return response.Choices[i].Index < response.Choices[j].Index
+	})
+
+	for i := range response.Choices {
+		message := &response.Choices[i].Message
+		if message.FunctionCall != nil {
+			functionCall := *message.FunctionCall
+			message.FunctionCall = &functionCall
+		}
+		if message.ToolCalls == nil {
+			continue
+		}
+
+		positions := a.toolCalls[response.Choices[i].Index]
+		indexes := make([]int, 0, len(positions))
+		for index := range positions {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+
+		toolCalls := make([]ToolCall, len(indexes))
+		for j, index := range indexes {
+			toolCalls[j] = message.ToolCalls[positions[index]]
+		}
+		message.ToolCalls = toolCalls
+	}
+
+	return response
+}
+
+// AccumulatingChatCompletionStream is a ChatCompletionStream which accumulates the received chunks.
+type AccumulatingChatCompletionStream struct {
+	*ChatCompletionStream
+	accumulator *ChatCompletionStreamAccumulator
+}
+
+func NewAccumulatingChatCompletionStream(stream *ChatCompletionStream) *AccumulatingChatCompletionStream {
+	return &AccumulatingChatCompletionStream{
+		ChatCompletionStream: stream,
+		accumulator:          NewChatCompletionStreamAccumulator(),
+	}
+}
+
+// Recv receives the next chunk of the stream and adds it to the accumulated response.
+func (stream *AccumulatingChatCompletionStream) Recv() (response ChatCompletionStreamResponse, err error) {
+	response, err = stream.ChatCompletionStream.Recv()
+	if err != nil {
+		return
+	}
+	stream.accumulator.AddChunk(response)
+	return
+}
+
+// Response returns the response accumulated so far, including the HTTP headers of the stream.
+func (stream *AccumulatingChatCompletionStream) Response() ChatCompletionResponse {
+	response := stream.accumulator.Response()
+	response.SetHeader(stream.Header())
+	return response
+}
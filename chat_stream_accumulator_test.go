@@ -0,0 +1,198 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestChatCompletionStreamAccumulator(t *testing.T) {
+	chunks := []openai.ChatCompletionStreamResponse{
+		{
+			ID: "chatcmpl-1", Created: 1598069254, Model: openai.GPT4,
+			Choices: []openai.ChatCompletionStreamChoice{
+				{Index: 1, Delta: openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}},
+				{Index: 0, Delta: openai.ChatCompletionStreamChoiceDelta{Role: openai.ChatMessageRoleAssistant}},
+			},
+		},
+		{
+			ID: "chatcmpl-1",
+			Choices: []openai.ChatCompletionStreamChoice{
+				{Index: 0, Delta: openai.ChatCompletionStreamChoiceDelta{Content: "Hello"}},
+				{Index: 1, Delta: openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{
+					{Index: intPtr(1), ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "get_time"}},
+					{
+						Index:    intPtr(0),
+						ID:       "call_1",
+						Type:     openai.ToolTypeFunction,
+						Function: openai.FunctionCall{Name: "get_weather"},
+					},
+				}}},
+			},
+		},
+		{
+			ID: "chatcmpl-1",
+			Choices: []openai.ChatCompletionStreamChoice{
+				{Index: 0, Delta: openai.ChatCompletionStreamChoiceDelta{Content: " world!"}},
+				{Index: 1, Delta: openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{
+					{Index: intPtr(0), Function: openai.FunctionCall{Arguments: `{"location":`}},
+					{Index: intPtr(1), Function: openai.FunctionCall{Arguments: `{}`}},
+				}}},
+			},
+		},
+		{
+			ID: "chatcmpl-1",
+			Choices: []openai.ChatCompletionStreamChoice{
+				{Index: 1, Delta: openai.ChatCompletionStreamChoiceDelta{ToolCalls: []openai.ToolCall{
+					{Index: intPtr(0), Function: openai.FunctionCall{Arguments: ` "Boston"}`}},
+				}}},
+				{Index: 0, FinishReason: openai.FinishReasonStop},
+			},
+		},
+		{
+			ID: "chatcmpl-1",
+			Choices: []openai.ChatCompletionStreamChoice{
+				{Index: 1, FinishReason: openai.FinishReasonToolCalls},
+			},
+		},
+	}
+
+	accumulator := openai.NewChatCompletionStreamAccumulator()
+	for _, chunk := range chunks {
+		accumulator.AddChunk(chunk)
+	}
+
+	want := openai.ChatCompletionResponse{
+		ID:      "chatcmpl-1",
+		Object:  "chat.completion",
+		Created: 1598069254,
+		Model:   openai.GPT4,
+		Choices: []openai.ChatCompletionChoice{
+			{
+				Index:        0,
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "Hello world!"},
+				FinishReason: openai.FinishReasonStop,
+			},
+			{
+				Index: 1,
+				Message: openai.ChatCompletionMessage{
+					Role: openai.ChatMessageRoleAssistant,
+					ToolCalls: []openai.ToolCall{
+						{
+							ID:       "call_1",
+							Type:     openai.ToolTypeFunction,
+							Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"location": "Boston"}`},
+						},
+						{
+							ID:       "call_2",
+							Type:     openai.ToolTypeFunction,
+							Function: openai.FunctionCall{Name: "get_time", Arguments: `{}`},
+						},
+					},
+				},
+				FinishReason: openai.FinishReasonToolCalls,
+			},
+		},
+	}
+
+	response := accumulator.Response()
+	if !reflect.DeepEqual(response, want) {
+		t.Fatalf("Response() = %+v, want %+v", response, want)
+	}
+
+	// the returned response is a copy
+	response.Choices[1].Message.ToolCalls[0].Function.Name = "changed"
+	if accumulator.Response().Choices[1].Message.ToolCalls[0].Function.Name != "get_weather" {
+		t.Fatalf("Response() shares memory with the accumulator")
+	}
+}
+
+func TestChatCompletionStreamAccumulatorFunctionCall(t *testing.T) {
+	accumulator := openai.NewChatCompletionStreamAccumulator()
+	accumulator.AddChunk(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{
+		Delta: openai.ChatCompletionStreamChoiceDelta{FunctionCall: &openai.FunctionCall{Name: "get_weather"}},
+	}}})
+	accumulator.AddChunk(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{
+		Delta: openai.ChatCompletionStreamChoiceDelta{FunctionCall: &openai.FunctionCall{Arguments: `{"location":`}},
+	}}})
+	accumulator.AddChunk(openai.ChatCompletionStreamResponse{Choices: []openai.ChatCompletionStreamChoice{{
+		Delta:        openai.ChatCompletionStreamChoiceDelta{FunctionCall: &openai.FunctionCall{Arguments: ` "Boston"}`}},
+		FinishReason: openai.FinishReasonFunctionCall,
+	}}})
+
+	choice := accumulator.Response().Choices[0]
+	want := openai.FunctionCall{Name: "get_weather", Arguments: `{"location": "Boston"}`}
+	if choice.Message.FunctionCall == nil || *choice.Message.FunctionCall != want {
+		t.Fatalf("unexpected function call: %+v", choice.Message.FunctionCall)
+	}
+	if choice.FinishReason != openai.FinishReasonFunctionCall {
+		t.Fatalf("FinishReason = %s, want %s", choice.FinishReason, openai.FinishReasonFunctionCall)
+	}
+}
+
+func TestAccumulatingChatCompletionStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("X-Request-Id", "req-1")
+
+		//nolint:lll
+		data := `data: {"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"gpt-4","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]},"finish_reason":null}]}
+
+data: {"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"gpt-4","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"location\":"}}]},"finish_reason":null}]}
+
+data: {"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"gpt-4","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":" \"Boston\"}"}}]},"finish_reason":null}]}
+
+data: {"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"gpt-4","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+		_, err := w.Write([]byte(data))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Weather in Boston?"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	accumulating := openai.NewAccumulatingChatCompletionStream(stream)
+	defer accumulating.Close()
+
+	chunks := 0
+	for {
+		_, err = accumulating.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		checks.NoError(t, err, "Recv error")
+		chunks++
+	}
+	if chunks != 4 {
+		t.Fatalf("Recv() returned %d chunks, want 4", chunks)
+	}
+
+	response := accumulating.Response()
+	if response.Header().Get("X-Request-Id") != "req-1" {
+		t.Fatalf("Response() doesn't have the headers of the stream")
+	}
+	if len(response.Choices) != 1 || response.Choices[0].FinishReason != openai.FinishReasonToolCalls {
+		t.Fatalf("unexpected choices: %+v", response.Choices)
+	}
+	toolCalls := response.Choices[0].Message.ToolCalls
+	if len(toolCalls) != 1 || toolCalls[0].ID != "call_1" || toolCalls[0].Function.Arguments != `{"location": "Boston"}` {
+		t.Fatalf("unexpected tool calls: %+v", toolCalls)
+	}
+}
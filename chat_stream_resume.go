@@ -0,0 +1,217 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const defaultStreamMaxResumes = 3
+
+var (
+	ErrResumableStreamMultipleChoices = errors.New("resumable streams don't support requests with N > 1")
+	ErrResumableStreamClosed          = errors.New("resumable stream was closed while resuming")
+	ErrResumableStreamMaxTokens       = errors.New("resumable stream received MaxTokens tokens before failing")
+)
+
+// StreamResumeOptions configures how a ResumableChatCompletionStream resumes.
+type StreamResumeOptions struct {
+	// MaxResumes limits the number of times the stream resumes, defaults to 3.
+	MaxResumes int
+	// ContinuePrompt is sent as a user message after the partial answer of the assistant when the
+	// stream resumes, e.g. "Continue exactly where you stopped." By default only the partial answer
+	// is sent.
+	ContinuePrompt string
+}
+
+// ResumableChatCompletionStream is a chat completion stream which resumes when the connection fails
+// before the completion is finished. It sends the request again with the content received so far
+// appended as an assistant message, and continues with the chunks of the new completion. The first
+// chunk of a continuation is marked as Resumed.
+//
+// The MaxTokens of a continuation is the MaxTokens of the request minus the tokens of the content
+// received so far, counted with Tokenize. When no tokens are left the stream fails with
+// ErrResumableStreamMaxTokens instead of resuming.
+//
+// Completions calling tools aren't resumed, since partial tool calls can't be continued.
+type ResumableChatCompletionStream struct {
+	client  *Client
+	ctx     context.Context
+	request ChatCompletionRequest
+	resume  StreamResumeOptions
+	opts    []StreamOption
+
+	// mutex guards stream and closed, which are accessed by Close
+	mutex    sync.Mutex
+	stream   *ChatCompletionStream
+	closed   bool
+	content  strings.Builder
+	finished bool
+	// toolCalls is true when the completion calls tools or functions
+	toolCalls bool
+	resumes   int
+	resumed   bool
+}
+
+// CreateResumableChatCompletionStream creates a chat completion stream which resumes when the connection
+// fails, see ResumableChatCompletionStream.
+func (c *Client) CreateResumableChatCompletionStream(
+	ctx context.Context,
+	request ChatCompletionRequest,
+	resume StreamResumeOptions,
+	opts ...StreamOption,
+) (*ResumableChatCompletionStream, error) {
+	if request.N > 1 {
+		return nil, ErrResumableStreamMultipleChoices
+	}
+	if resume.MaxResumes == 0 {
+		resume.MaxResumes = defaultStreamMaxResumes
+	}
+
+	stream, err := c.CreateChatCompletionStream(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ResumableChatCompletionStream{
+		client:  c,
+		ctx:     ctx,
+		request: request,
+		resume:  resume,
+		opts:    opts,
+		stream:  stream,
+	}, nil
+}
+
+// Recv receives the next chunk, resuming the stream when the connection failed.
+func (stream *ResumableChatCompletionStream) Recv() (ChatCompletionStreamResponse, error) {
+	for {
+		response, err := stream.stream.Recv()
+		if err == nil {
+			stream.addChunk(response)
+			response.Resumed, stream.resumed = stream.resumed, false
+			return response, nil
+		}
+
+		if !stream.canResume(err) {
+			return response, err
+		}
+		if resumeErr := stream.resumeStream(); resumeErr != nil {
+			return ChatCompletionStreamResponse{}, resumeErr
+		}
+	}
+}
+
+func (stream *ResumableChatCompletionStream) addChunk(response ChatCompletionStreamResponse) {
+	for _, choice := range response.Choices {
+		stream.content.WriteString(choice.Delta.Content)
+		if len(choice.Delta.ToolCalls) > 0 || choice.Delta.FunctionCall != nil {
+			stream.toolCalls = true
+		}
+		if choice.FinishReason != "" {
+			stream.finished = true
+		}
+	}
+}
+
+// canResume reports whether the error is a failure of the connection of an unfinished completion.
+func (stream *ResumableChatCompletionStream) canResume(err error) bool {
+	if stream.finished || stream.toolCalls || stream.resumes >= stream.resume.MaxResumes || stream.ctx.Err() != nil {
+		return false
+	}
+	stream.mutex.Lock()
+	closed := stream.closed
+	stream.mutex.Unlock()
+	if closed {
+		return false
+	}
+	if errors.Is(err, io.EOF) {
+		// the stream ended without [DONE]
+		return !stream.stream.isFinished
+	}
+
+	var (
+		apiErr     *APIError
+		requestErr *RequestError
+	)
+	return !errors.As(err, &apiErr) && !errors.As(err, &requestErr) && !errors.Is(err, ErrTooManyEmptyStreamMessages)
+}
+
+func (stream *ResumableChatCompletionStream) resumeStream() error {
+	stream.stream.Close()
+
+	request := stream.request
+	if request.MaxTokens > 0 && stream.content.Len() > 0 {
+		ids, _, err := Tokenize(request.Model, stream.content.String())
+		if err != nil {
+			return err
+		}
+		request.MaxTokens -= len(ids)
+		if request.MaxTokens <= 0 {
+			return ErrResumableStreamMaxTokens
+		}
+	}
+
+	request.Messages = append([]ChatCompletionMessage(nil), stream.request.Messages...)
+	if stream.content.Len() > 0 {
+		request.Messages = append(request.Messages, ChatCompletionMessage{
+			Role:    ChatMessageRoleAssistant,
+			Content: stream.content.String(),
+		})
+		if stream.resume.ContinuePrompt != "" {
+			request.Messages = append(request.Messages, ChatCompletionMessage{
+				Role:    ChatMessageRoleUser,
+				Content: stream.resume.ContinuePrompt,
+			})
+		}
+	}
+
+	next, err := stream.client.CreateChatCompletionStream(stream.ctx, request, stream.opts...)
+	if err != nil {
+		return err
+	}
+
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	if stream.closed {
+		next.Close()
+		return ErrResumableStreamClosed
+	}
+	stream.stream = next
+	stream.resumes++
+	stream.resumed = true
+	return nil
+}
+
+// Resumes returns the number of times the stream resumed.
+func (stream *ResumableChatCompletionStream) Resumes() int {
+	return stream.resumes
+}
+
+// Header returns the HTTP headers of the current stream.
+func (stream *ResumableChatCompletionStream) Header() http.Header {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	return stream.stream.Header()
+}
+
+func (stream *ResumableChatCompletionStream) Close() {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+	stream.closed = true
+	stream.stream.Close()
+}
+
+// Channel is like ChatCompletionStream.Channel, resuming the stream.
+func (stream *ResumableChatCompletionStream) Channel(
+	ctx context.Context,
+) (<-chan ChatCompletionStreamResponse, <-chan error) {
+	return streamChannel(ctx, stream.Recv, stream.Close)
+}
+
+// All is like ChatCompletionStream.All, resuming the stream.
+func (stream *ResumableChatCompletionStream) All() func(yield func(ChatCompletionStreamResponse, error) bool) {
+	return streamIterator(stream.Recv, stream.Close)
+}
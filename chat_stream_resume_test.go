@@ -0,0 +1,143 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func TestResumableChatCompletionStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var requests []openai.ChatCompletionRequest
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatCompletionRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		requests = append(requests, request)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		var data string
+		switch len(requests) {
+		case 1:
+			// the connection drops in the middle of the second chunk
+			data = `data: {"id":"1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}` + "\n\n" +
+				`data: {"id":"1","choices":[{"ind`
+		case 2:
+			// the connection drops without [DONE]
+			data = `data: {"id":"2","choices":[{"index":0,"delta":{"content":" wor"}}]}` + "\n\n"
+		default:
+			data = `data: {"id":"3","choices":[{"index":0,"delta":{"content":"ld!"},"finish_reason":"stop"}]}` + "\n\n" +
+				"data: [DONE]\n\n"
+		}
+		_, err = w.Write([]byte(data))
+		checks.NoError(t, err, "Write error")
+	})
+
+	request := openai.ChatCompletionRequest{
+		Model:     openai.GPT3Dot5Turbo,
+		Messages:  []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		MaxTokens: 10,
+	}
+	stream, err := client.CreateResumableChatCompletionStream(context.Background(), request,
+		openai.StreamResumeOptions{ContinuePrompt: "Continue."})
+	checks.NoError(t, err, "CreateResumableChatCompletionStream error")
+	defer stream.Close()
+
+	var (
+		content string
+		resumed []bool
+	)
+	for {
+		var chunk openai.ChatCompletionStreamResponse
+		chunk, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		checks.NoError(t, err, "Recv error")
+		content += chunk.Choices[0].Delta.Content
+		resumed = append(resumed, chunk.Resumed)
+	}
+
+	if content != "Hello world!" || stream.Resumes() != 2 || len(requests) != 3 {
+		t.Fatalf("unexpected content %q after %d resumes", content, stream.Resumes())
+	}
+	if len(resumed) != 3 || resumed[0] || !resumed[1] || !resumed[2] {
+		t.Fatalf("the resumed chunks aren't marked: %v", resumed)
+	}
+	messages := requests[2].Messages
+	if roles := messageRoles(messages); roles != "user,assistant,user" {
+		t.Fatalf("unexpected messages: %s", roles)
+	}
+	if messages[1].Content != "Hello wor" || messages[2].Content != "Continue." {
+		t.Fatalf("the partial answer wasn't sent: %+v", messages)
+	}
+
+	// the continuations only complete the tokens left
+	for i, received := range []string{"Hello", "Hello wor"} {
+		ids, _, tokenizeErr := openai.Tokenize(request.Model, received)
+		checks.NoError(t, tokenizeErr, "Tokenize error")
+		if maxTokens := requests[i+1].MaxTokens; maxTokens != 10-len(ids) {
+			t.Fatalf("continuation %d has MaxTokens %d, want %d", i+1, maxTokens, 10-len(ids))
+		}
+	}
+}
+
+func TestResumableChatCompletionStreamErrors(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	requests := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/event-stream")
+		data := `data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n"
+		if requests > 1 {
+			data = `data: {"error":{"message":"The server had an error","type":"server_error"}}` + "\n\n"
+		}
+		_, err := w.Write([]byte(data))
+		checks.NoError(t, err, "Write error")
+	})
+
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+	stream, err := client.CreateResumableChatCompletionStream(context.Background(), request,
+		openai.StreamResumeOptions{})
+	checks.NoError(t, err, "CreateResumableChatCompletionStream error")
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	// the error events of the resumed stream aren't resumed
+	_, err = stream.Recv()
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || stream.Resumes() != 1 || requests != 2 {
+		t.Fatalf("Recv() error = %v after %d resumes, want the APIError of the resumed stream", err, stream.Resumes())
+	}
+
+	// the stream isn't resumed when the content received has MaxTokens tokens
+	requests = 0
+	request.MaxTokens = 1
+	stream, err = client.CreateResumableChatCompletionStream(context.Background(), request,
+		openai.StreamResumeOptions{})
+	checks.NoError(t, err, "CreateResumableChatCompletionStream error")
+	defer stream.Close()
+	_, err = stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, openai.ErrResumableStreamMaxTokens, "Recv should fail without tokens left")
+	if requests != 1 {
+		t.Fatalf("the stream resumed with %d requests", requests)
+	}
+
+	request.N = 2
+	_, err = client.CreateResumableChatCompletionStream(context.Background(), request, openai.StreamResumeOptions{})
+	checks.ErrorIs(t, err, openai.ErrResumableStreamMultipleChoices, "N > 1 should be rejected")
+}
@@ -261,6 +261,55 @@ func TestCreateChatCompletionStreamWithRatelimitHeaders(t *testing.T) {
 	}
 }
 
+func TestCreateChatCompletionStreamWithUsage(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatCompletionRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		if request.StreamOptions == nil || !request.StreamOptions.IncludeUsage {
+			t.Errorf("the stream options weren't sent: %+v", request.StreamOptions)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		//nolint:lll
+		dataBytes := []byte(`data: {"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"gpt-3.5-turbo","system_fingerprint":"fp_3bc1b5746c","choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":"stop"}],"usage":null}` + "\n\n" +
+			`data: {"id":"1","object":"chat.completion.chunk","created":1598069254,"model":"gpt-3.5-turbo","system_fingerprint":"fp_3bc1b5746c","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":1,"total_tokens":10}}` + "\n\n" +
+			"data: [DONE]\n\n")
+		_, err = w.Write(dataBytes)
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:         openai.GPT3Dot5Turbo,
+		Messages:      []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+		StreamOptions: &openai.StreamOptions{IncludeUsage: true},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	accumulating := openai.NewAccumulatingChatCompletionStream(stream)
+	defer accumulating.Close()
+
+	chunk, err := accumulating.Recv()
+	checks.NoError(t, err, "Recv error")
+	if chunk.Usage != nil || chunk.SystemFingerprint != "fp_3bc1b5746c" {
+		t.Fatalf("unexpected first chunk: %+v", chunk)
+	}
+	chunk, err = accumulating.Recv()
+	checks.NoError(t, err, "Recv error")
+	want := openai.Usage{PromptTokens: 9, CompletionTokens: 1, TotalTokens: 10}
+	if len(chunk.Choices) != 0 || chunk.Usage == nil || *chunk.Usage != want {
+		t.Fatalf("unexpected usage chunk: %+v", chunk)
+	}
+	_, err = accumulating.Recv()
+	checks.ErrorIs(t, err, io.EOF, "Recv should return io.EOF after [DONE]")
+
+	response := accumulating.Response()
+	if response.Usage != want || response.SystemFingerprint != "fp_3bc1b5746c" {
+		t.Fatalf("the usage wasn't accumulated: %+v", response)
+	}
+}
+
 func TestCreateChatCompletionStreamErrorWithDataPrefix(t *testing.T) {
 	client, server, teardown := setupOpenAITestServer()
 	defer teardown()
@@ -502,19 +502,20 @@ func TestChatCompletionRequest_Tokens(t *testing.T) {
 		name       string
 		model      string
 		messages   []openai.ChatCompletionMessage
-		wantErr    error
 		wantTokens int
 	}{
 		{
-			name:    "test unknown model",
-			model:   "unknown",
-			wantErr: errors.New("failed to tokenize prompt: model not supported: model not supported"),
+			name:  "test unknown model falls back to cl100k_base",
+			model: "unknown",
+			// "Hello!" is 2 tokens, plus the message and reply overheads
+			messages:   []openai.ChatCompletionMessage{{Content: "Hello!"}},
+			wantTokens: 8,
 		},
 		{
 			name:       "test1",
 			model:      openai.GPT3Dot5Turbo,
 			messages:   []openai.ChatCompletionMessage{{Content: "Hello!"}},
-			wantTokens: 2,
+			wantTokens: 8,
 		},
 	}
 
@@ -525,14 +526,7 @@ func TestChatCompletionRequest_Tokens(t *testing.T) {
 				Messages: testcase.messages,
 			}
 			tokens, err := req.Tokens()
-			if err != nil && testcase.wantErr == nil {
-				tt.Fatalf("Tokens() returned unexpected error: %v", err)
-			}
-
-			if err != nil && testcase.wantErr != nil && err.Error() != testcase.wantErr.Error() {
-				tt.Fatalf("Tokens() returned unexpected error: %v, want: %v", err, testcase.wantErr)
-			}
-
+			checks.NoError(tt, err, "Tokens() returned unexpected error")
 			if tokens != testcase.wantTokens {
 				tt.Fatalf("Tokens() returned unexpected number of tokens: %d, want: %d", tokens, testcase.wantTokens)
 			}
@@ -0,0 +1,395 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"  // register decoders for image sizes of data URLs
+	_ "image/jpeg" // register decoders for image sizes of data URLs
+	_ "image/png"  // register decoders for image sizes of data URLs
+	"math"
+	"sort"
+	"strings"
+)
+
+// Token accounting of chat completion requests, see
+// https://github.com/openai/openai-cookbook/blob/main/examples/How_to_count_tokens_with_tiktoken.ipynb
+// https://platform.openai.com/docs/guides/vision/calculating-costs
+const (
+	// every reply is primed with <|start|>assistant<|message|>
+	chatReplyPrimingTokens = 3
+	// function and tool definitions are wrapped into a system message
+	chatFunctionsOverheadTokens = 9
+	// calls of functions and tools are wrapped into their own message
+	chatFunctionCallOverheadTokens = 3
+
+	imageLowDetailTokens = 85
+	imageTileTokens      = 170
+	imageTileSize        = 512
+	imageMaxSize         = 2048
+	imageShortSideSize   = 768
+)
+
+// ChatTokenCounter estimates the number of prompt tokens billed for chat completion requests.
+// The estimation follows the accounting published in the OpenAI cookbook, including the
+// overhead of messages, names, function and tool definitions and images.
+type ChatTokenCounter struct {
+	// ImageSize returns the width and height of the image at url. It is used for images
+	// with high or auto detail which aren't data URLs. When it's nil or fails, the
+	// image is counted as a square image scaled to 768x768 pixels.
+	ImageSize func(url string) (width, height int, err error)
+}
+
+// RequestTokens returns the number of prompt tokens of the request.
+func (c ChatTokenCounter) RequestTokens(request ChatCompletionRequest) (tokens int, err error) {
+	functions := request.Functions
+	for _, tool := range request.Tools {
+		functions = append(functions, tool.Function)
+	}
+
+	hasSystemMessage := false
+	for _, message := range request.Messages {
+		if message.Role == ChatMessageRoleSystem && len(functions) > 0 && !hasSystemMessage {
+			// function definitions are appended to the first system message
+			message.Content += "\n"
+		}
+		hasSystemMessage = hasSystemMessage || message.Role == ChatMessageRoleSystem
+
+		var messageTokens int
+		messageTokens, err = c.MessageTokens(request.Model, message)
+		if err != nil {
+			return
+		}
+		tokens += messageTokens
+	}
+	tokens += chatReplyPrimingTokens
+
+	if len(functions) > 0 {
+		var functionsTokens int
+		functionsTokens, err = FunctionDefinitionsTokens(request.Model, functions)
+		if err != nil {
+			return
+		}
+		tokens += functionsTokens
+		if hasSystemMessage {
+			tokens -= 4
+		}
+	}
+
+	var choiceTokens int
+	choiceTokens, err = functionChoiceTokens(request.Model, request.FunctionCall)
+	if err != nil {
+		return
+	}
+	tokens += choiceTokens
+
+	choiceTokens, err = functionChoiceTokens(request.Model, request.ToolChoice)
+	if err != nil {
+		return
+	}
+	tokens += choiceTokens
+
+	return tokens, nil
+}
+
+// MessageTokens returns the number of prompt tokens of a single message, without the reply priming.
+func (c ChatTokenCounter) MessageTokens(model string, message ChatCompletionMessage) (tokens int, err error) {
+	perMessage, perName := chatMessageOverhead(model)
+
+	// the ID of the call answered by a tool message is part of the message
+	texts := []string{message.Role, message.Content, message.ToolCallID}
+	for _, part := range message.MultiContent {
+		switch {
+		case part.Type == ChatMessagePartTypeImageURL && part.ImageURL != nil:
+			tokens += c.imageURLTokens(part.ImageURL)
+		default:
+			texts = append(texts, part.Text)
+		}
+	}
+
+	if message.Name != "" {
+		texts = append(texts, message.Name)
+		tokens += perName
+	}
+
+	calls := message.ToolCalls
+	if message.FunctionCall != nil {
+		calls = append(calls, ToolCall{Function: *message.FunctionCall})
+	}
+	for _, call := range calls {
+		texts = append(texts, call.Function.Name, call.Function.Arguments)
+		tokens += chatFunctionCallOverheadTokens
+	}
+
+	textTokens, err := countTokens(model, texts...)
+	if err != nil {
+		return
+	}
+	tokens += perMessage + textTokens
+
+	if message.Role == ChatMessageRoleFunction || message.Role == ChatMessageRoleTool {
+		tokens -= 2
+	}
+
+	return tokens, nil
+}
+
+func (c ChatTokenCounter) imageURLTokens(imageURL *ChatMessageImageURL) int {
+	if imageURL.Detail == ImageURLDetailLow {
+		return imageLowDetailTokens
+	}
+
+	width, height, err := dataURLImageSize(imageURL.URL)
+	if err != nil && c.ImageSize != nil {
+		width, height, err = c.ImageSize(imageURL.URL)
+	}
+	if err != nil {
+		width, height = imageShortSideSize, imageShortSideSize
+	}
+
+	return ImageTokens(width, height, imageURL.Detail)
+}
+
+// ImageTokens returns the number of tokens of an image with the given size and detail.
+// Images with auto detail are counted as high detail images.
+func ImageTokens(width, height int, detail ImageURLDetail) int {
+	if detail == ImageURLDetailLow {
+		return imageLowDetailTokens
+	}
+
+	if width <= 0 || height <= 0 {
+		width, height = imageShortSideSize, imageShortSideSize
+	}
+
+	w, h := float64(width), float64(height)
+	// scale to fit within a 2048x2048 square
+	if longSide := math.Max(w, h); longSide > imageMaxSize {
+		w, h = w*imageMaxSize/longSide, h*imageMaxSize/longSide
+	}
+	// scale so that the shortest side is 768px long
+	if shortSide := math.Min(w, h); shortSide > imageShortSideSize {
+		w, h = w*imageShortSideSize/shortSide, h*imageShortSideSize/shortSide
+	}
+
+	tiles := math.Ceil(w/imageTileSize) * math.Ceil(h/imageTileSize)
+	return imageLowDetailTokens + imageTileTokens*int(tiles)
+}
+
+// dataURLImageSize decodes the size of an image embedded into a data URL.
+func dataURLImageSize(url string) (width, height int, err error) {
+	const prefix = "data:"
+	if !strings.HasPrefix(url, prefix) {
+		return 0, 0, fmt.Errorf("not a data URL")
+	}
+
+	meta, data, ok := strings.Cut(strings.TrimPrefix(url, prefix), ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return 0, 0, fmt.Errorf("not a base64 data URL")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return 0, 0, err
+	}
+	return config.Width, config.Height, nil
+}
+
+// FunctionDefinitionsTokens returns the number of tokens of function or tool definitions.
+// The definitions are rendered the way the model receives them: as a TypeScript namespace.
+func FunctionDefinitionsTokens(model string, functions []FunctionDefinition) (int, error) {
+	definitions, err := formatFunctionDefinitions(functions)
+	if err != nil {
+		return 0, err
+	}
+
+	tokens, err := countTokens(model, definitions)
+	if err != nil {
+		return 0, err
+	}
+	return tokens + chatFunctionsOverheadTokens, nil
+}
+
+// chatMessageOverhead returns the tokens added to every message and to every name.
+func chatMessageOverhead(model string) (perMessage, perName int) {
+	if strings.HasPrefix(model, GPT3Dot5Turbo0301) {
+		// every message follows <|start|>{role/name}\n{content}<|end|>\n
+		// if there's a name, the role is omitted
+		return 4, -1
+	}
+	return 3, 1
+}
+
+// functionChoiceTokens returns the number of tokens of the function_call or tool_choice parameter.
+func functionChoiceTokens(model string, choice any) (int, error) {
+	var name string
+	switch v := choice.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		if v == "none" {
+			return 1, nil
+		}
+		return 0, nil
+	case ToolChoice:
+		name = v.Function.Name
+	case *ToolChoice:
+		if v == nil {
+			return 0, nil
+		}
+		name = v.Function.Name
+	case FunctionCall:
+		name = v.Name
+	case *FunctionCall:
+		if v == nil {
+			return 0, nil
+		}
+		name = v.Name
+	default:
+		return 0, nil
+	}
+
+	tokens, err := countTokens(model, name)
+	if err != nil {
+		return 0, err
+	}
+	return tokens + 4, nil
+}
+
+func countTokens(model string, texts ...string) (tokens int, err error) {
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+
+		var ids []uint
+		ids, _, err = Tokenize(model, text)
+		if err != nil {
+			err = fmt.Errorf("failed to tokenize prompt: %w", err)
+			return
+		}
+		tokens += len(ids)
+	}
+	return
+}
+
+// functionSchema is the subset of a JSON schema used to render function definitions.
+type functionSchema struct {
+	Type        any                       `json:"type"`
+	Description string                    `json:"description"`
+	Enum        []any                     `json:"enum"`
+	Properties  map[string]functionSchema `json:"properties"`
+	Required    []string                  `json:"required"`
+	Items       *functionSchema           `json:"items"`
+}
+
+func formatFunctionDefinitions(functions []FunctionDefinition) (string, error) {
+	lines := []string{"namespace functions {", ""}
+	for _, f := range functions {
+		if f.Description != "" {
+			lines = append(lines, "// "+f.Description)
+		}
+
+		var params functionSchema
+		if f.Parameters != nil {
+			b, err := json.Marshal(f.Parameters)
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal parameters of function %s: %w", f.Name, err)
+			}
+			if err = json.Unmarshal(b, &params); err != nil {
+				return "", fmt.Errorf("failed to decode parameters of function %s: %w", f.Name, err)
+			}
+		}
+
+		if len(params.Properties) > 0 {
+			lines = append(lines,
+				"type "+f.Name+" = (_: {",
+				formatSchemaProperties(params, 0),
+				"}) => any;",
+			)
+		} else {
+			lines = append(lines, "type "+f.Name+" = () => any;")
+		}
+		lines = append(lines, "")
+	}
+	lines = append(lines, "} // namespace functions")
+	return strings.Join(lines, "\n"), nil
+}
+
+func formatSchemaProperties(schema functionSchema, indent int) string {
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		property := schema.Properties[name]
+		if property.Description != "" && indent < 2 {
+			lines = append(lines, "// "+property.Description)
+		}
+
+		if contains(schema.Required, name) {
+			lines = append(lines, name+": "+formatSchemaType(property, indent)+",")
+		} else {
+			lines = append(lines, name+"?: "+formatSchemaType(property, indent)+",")
+		}
+	}
+
+	padding := strings.Repeat(" ", indent)
+	for i := range lines {
+		lines[i] = padding + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatSchemaType(schema functionSchema, indent int) string {
+	// nullable types are encoded as type arrays, e.g. ["string", "null"]
+	if types, ok := schema.Type.([]any); ok {
+		formatted := make([]string, 0, len(types))
+		for _, t := range types {
+			schema.Type = t
+			formatted = append(formatted, formatSchemaType(schema, indent))
+		}
+		return strings.Join(formatted, " | ")
+	}
+
+	typ, _ := schema.Type.(string)
+	switch typ {
+	case "string", "number", "integer":
+		if len(schema.Enum) == 0 {
+			if typ == "integer" {
+				return "number"
+			}
+			return typ
+		}
+
+		values := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			if s, ok := value.(string); ok {
+				values[i] = `"` + s + `"`
+			} else {
+				values[i] = fmt.Sprint(value)
+			}
+		}
+		return strings.Join(values, " | ")
+	case "boolean", "null":
+		return typ
+	case "object":
+		return "{\n" + formatSchemaProperties(schema, indent+2) + "\n}"
+	case "array":
+		if schema.Items != nil {
+			return formatSchemaType(*schema.Items, indent) + "[]"
+		}
+		return "any[]"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,277 @@
+package openai_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// cookbookMessages are the example messages of the OpenAI cookbook on counting tokens.
+var cookbookMessages = []openai.ChatCompletionMessage{
+	{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: "You are a helpful, pattern-following assistant that translates corporate jargon into plain English.",
+	},
+	{
+		Role:    openai.ChatMessageRoleSystem,
+		Name:    "example_user",
+		Content: "New synergies will help drive top-line growth.",
+	},
+	{
+		Role:    openai.ChatMessageRoleSystem,
+		Name:    "example_assistant",
+		Content: "Things working together will increase revenue.",
+	},
+	{
+		Role:    openai.ChatMessageRoleSystem,
+		Name:    "example_user",
+		Content: "Let's circle back when we have more bandwidth to touch base on opportunities for increased leverage.",
+	},
+	{
+		Role:    openai.ChatMessageRoleSystem,
+		Name:    "example_assistant",
+		Content: "Let's talk later when we're less busy about how to do better.",
+	},
+	{
+		Role:    openai.ChatMessageRoleUser,
+		Content: "This late pivot means we don't have time to boil the ocean for the client deliverable.",
+	},
+}
+
+func TestChatTokenCounterCookbook(t *testing.T) {
+	count := func(model, text string) int {
+		ids, _, err := openai.Tokenize(model, text)
+		checks.NoError(t, err, "Tokenize error")
+		return len(ids)
+	}
+
+	testcases := []struct {
+		model               string
+		perMessage, perName int
+	}{
+		{openai.GPT3Dot5Turbo0301, 4, -1},
+		{openai.GPT3Dot5Turbo0613, 3, 1},
+		{openai.GPT3Dot5Turbo, 3, 1},
+		{openai.GPT4, 3, 1},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.model, func(t *testing.T) {
+			// every reply is primed with <|start|>assistant<|message|>
+			wantTokens := 3
+			for _, message := range cookbookMessages {
+				wantTokens += testcase.perMessage + count(testcase.model, message.Role) + count(testcase.model, message.Content)
+				if message.Name != "" {
+					wantTokens += testcase.perName + count(testcase.model, message.Name)
+				}
+			}
+
+			tokens, err := openai.ChatCompletionRequest{
+				Model:    testcase.model,
+				Messages: cookbookMessages,
+			}.Tokens()
+			checks.NoError(t, err, "Tokens error")
+			if tokens != wantTokens {
+				t.Fatalf("Tokens() = %d, want %d", tokens, wantTokens)
+			}
+		})
+	}
+}
+
+func TestImageTokens(t *testing.T) {
+	testcases := []struct {
+		width, height int
+		detail        openai.ImageURLDetail
+		wantTokens    int
+	}{
+		{4096, 8192, openai.ImageURLDetailLow, 85},
+		{1024, 1024, openai.ImageURLDetailHigh, 765},
+		{2048, 4096, openai.ImageURLDetailHigh, 1105},
+		{100, 100, openai.ImageURLDetailAuto, 255},
+		{0, 0, openai.ImageURLDetailHigh, 765},
+	}
+
+	for _, testcase := range testcases {
+		tokens := openai.ImageTokens(testcase.width, testcase.height, testcase.detail)
+		if tokens != testcase.wantTokens {
+			t.Errorf("ImageTokens(%d, %d, %q) = %d, want %d",
+				testcase.width, testcase.height, testcase.detail, tokens, testcase.wantTokens)
+		}
+	}
+}
+
+func TestChatTokenCounterImages(t *testing.T) {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, image.NewGray(image.Rect(0, 0, 100, 100)))
+	checks.NoError(t, err, "png.Encode error")
+	dataURL := "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	message := func(url string, detail openai.ImageURLDetail) openai.ChatCompletionMessage {
+		return openai.ChatCompletionMessage{
+			Role: openai.ChatMessageRoleUser,
+			MultiContent: []openai.ChatMessagePart{
+				{Type: openai.ChatMessagePartTypeText, Text: "What's in this image?"},
+				{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{URL: url, Detail: detail}},
+			},
+		}
+	}
+
+	counter := openai.ChatTokenCounter{}
+	text, err := counter.MessageTokens(openai.GPT4VisionPreview, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: "What's in this image?",
+	})
+	checks.NoError(t, err, "MessageTokens error")
+
+	testcases := []struct {
+		name       string
+		counter    openai.ChatTokenCounter
+		message    openai.ChatCompletionMessage
+		wantTokens int
+	}{
+		{"low detail", counter, message("https://example.com/image.png", openai.ImageURLDetailLow), text + 85},
+		{"unknown size", counter, message("https://example.com/image.png", openai.ImageURLDetailHigh), text + 765},
+		{"data URL", counter, message(dataURL, openai.ImageURLDetailAuto), text + 255},
+		{
+			"image size",
+			openai.ChatTokenCounter{ImageSize: func(string) (int, int, error) { return 2048, 4096, nil }},
+			message("https://example.com/image.png", openai.ImageURLDetailHigh),
+			text + 1105,
+		},
+		{
+			"image size error",
+			openai.ChatTokenCounter{ImageSize: func(string) (int, int, error) { return 0, 0, errors.New("not found") }},
+			message("https://example.com/image.png", openai.ImageURLDetailHigh),
+			text + 765,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			tokens, err := testcase.counter.MessageTokens(openai.GPT4VisionPreview, testcase.message)
+			checks.NoError(t, err, "MessageTokens error")
+			if tokens != testcase.wantTokens {
+				t.Fatalf("MessageTokens() = %d, want %d", tokens, testcase.wantTokens)
+			}
+		})
+	}
+}
+
+func TestChatTokenCounterTools(t *testing.T) {
+	weather := openai.FunctionDefinition{
+		Name:        "get_current_weather",
+		Description: "Get the current weather in a given location",
+		Parameters: jsonschema.Definition{
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"location": {Type: jsonschema.String, Description: "The city and state, e.g. San Francisco, CA"},
+				"unit":     {Type: jsonschema.String, Enum: []string{"celsius", "fahrenheit"}},
+			},
+			Required: []string{"location"},
+		},
+	}
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: "What's the weather like in Boston?"},
+	}
+
+	plain, err := openai.ChatCompletionRequest{Model: openai.GPT4, Messages: messages}.Tokens()
+	checks.NoError(t, err, "Tokens error")
+
+	definitions, err := openai.FunctionDefinitionsTokens(openai.GPT4, []openai.FunctionDefinition{weather})
+	checks.NoError(t, err, "FunctionDefinitionsTokens error")
+	if definitions <= 9 {
+		t.Fatalf("FunctionDefinitionsTokens() = %d, the definition itself isn't counted", definitions)
+	}
+
+	tools, err := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: messages,
+		Tools:    []openai.Tool{{Type: openai.ToolTypeFunction, Function: weather}},
+	}.Tokens()
+	checks.NoError(t, err, "Tokens error")
+	if tools != plain+definitions {
+		t.Fatalf("Tokens() with tools = %d, want %d", tools, plain+definitions)
+	}
+
+	functions, err := openai.ChatCompletionRequest{
+		Model:        openai.GPT4,
+		Messages:     messages,
+		Functions:    []openai.FunctionDefinition{weather},
+		FunctionCall: "none",
+	}.Tokens()
+	checks.NoError(t, err, "Tokens error")
+	if functions != tools+1 {
+		t.Fatalf("Tokens() with functions = %d, want %d", functions, tools+1)
+	}
+
+	withSystem := append([]openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "Be brief."},
+	}, messages...)
+	systemPlain, err := openai.ChatCompletionRequest{Model: openai.GPT4, Messages: withSystem}.Tokens()
+	checks.NoError(t, err, "Tokens error")
+	systemTools, err := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: withSystem,
+		Tools:    []openai.Tool{{Type: openai.ToolTypeFunction, Function: weather}},
+	}.Tokens()
+	checks.NoError(t, err, "Tokens error")
+	// the definitions are merged into the system message, "Be brief.\n" has as many tokens as "Be brief."
+	if systemTools != systemPlain+definitions-4 {
+		t.Fatalf("Tokens() with tools and system message = %d, want %d", systemTools, systemPlain+definitions-4)
+	}
+}
+
+func TestChatTokenCounterToolCalls(t *testing.T) {
+	call := openai.FunctionCall{Name: "get_current_weather", Arguments: `{"location": "Boston, MA"}`}
+	counter := openai.ChatTokenCounter{}
+
+	assistant, err := counter.MessageTokens(openai.GPT4, openai.ChatCompletionMessage{
+		Role:      openai.ChatMessageRoleAssistant,
+		ToolCalls: []openai.ToolCall{{ID: "call_1", Type: openai.ToolTypeFunction, Function: call}},
+	})
+	checks.NoError(t, err, "MessageTokens error")
+
+	deprecated, err := counter.MessageTokens(openai.GPT4, openai.ChatCompletionMessage{
+		Role:         openai.ChatMessageRoleAssistant,
+		FunctionCall: &call,
+	})
+	checks.NoError(t, err, "MessageTokens error")
+	if assistant != deprecated || assistant <= 3+3 {
+		t.Fatalf("MessageTokens() of tool call = %d, of function call = %d", assistant, deprecated)
+	}
+
+	named, err := counter.MessageTokens(openai.GPT4, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Name:    "bob",
+		Content: "Hello!",
+	})
+	checks.NoError(t, err, "MessageTokens error")
+	anonymous, err := counter.MessageTokens(openai.GPT4, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleUser,
+		Content: "Hello!",
+	})
+	checks.NoError(t, err, "MessageTokens error")
+	// "bob" is a single token, plus one token for the name
+	if named != anonymous+2 {
+		t.Fatalf("MessageTokens() with name = %d, want %d", named, anonymous+2)
+	}
+
+	result := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleTool, Content: `{"temperature": 22}`}
+	withoutID, err := counter.MessageTokens(openai.GPT4, result)
+	checks.NoError(t, err, "MessageTokens error")
+	result.ToolCallID = "call_1"
+	withID, err := counter.MessageTokens(openai.GPT4, result)
+	checks.NoError(t, err, "MessageTokens error")
+	ids, _, err := openai.Tokenize(openai.GPT4, result.ToolCallID)
+	checks.NoError(t, err, "Tokenize error")
+	if withID != withoutID+len(ids) {
+		t.Fatalf("MessageTokens() with tool call ID = %d, want %d", withID, withoutID+len(ids))
+	}
+}
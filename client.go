@@ -4,14 +4,18 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	utils "github.com/sashabaranov/go-openai/internal"
 )
 
+var errRequestBodyNotReplayable = errors.New("request body can't be replayed")
+
 // Client is OpenAI GPT-3 API client.
 type Client struct {
 	config ClientConfig
@@ -55,8 +59,10 @@ func NewClientWithConfig(config ClientConfig) *Client {
 		},
 	}
 
-	if c.config.EnableRateLimiter {
-		c.rateLimiter = NewMemRateLimiter(c.config.APIType)
+	if c.config.RateLimiter != nil {
+		c.rateLimiter = c.config.RateLimiter
+	} else if c.config.EnableRateLimiter {
+		c.rateLimiter = NewAdaptiveRateLimiter(c.config.APIType)
 	}
 
 	return c
@@ -72,8 +78,9 @@ func NewOrgClient(authToken, org string) *Client {
 }
 
 type requestOptions struct {
-	body   any
-	header http.Header
+	body           any
+	header         http.Header
+	rateLimitModel string
 }
 
 type requestOption func(*requestOptions)
@@ -105,6 +112,10 @@ func (c *Client) newRequest(ctx context.Context, method, url string, setters ...
 	for _, setter := range setters {
 		setter(args)
 	}
+	ctx = withRequestPayload(ctx, args.body)
+	if args.rateLimitModel != "" {
+		ctx = context.WithValue(ctx, rateLimitModelKey{}, args.rateLimitModel)
+	}
 	req, err := c.requestBuilder.Build(ctx, method, url, args.body, args.header)
 	if err != nil {
 		return nil, err
@@ -123,17 +134,13 @@ func (c *Client) sendRequest(req *http.Request, v Response) error {
 		req.Header.Set("Content-Type", "application/json; charset=utf-8")
 	}
 
-	res, err := c.config.HTTPClient.Do(req)
+	res, err := c.doRequest(req)
 	if err != nil {
 		return err
 	}
 
 	defer res.Body.Close()
 
-	if isFailureStatusCode(res) {
-		return c.handleErrorResp(res)
-	}
-
 	if v != nil {
 		v.SetHeader(res.Header)
 	}
@@ -142,32 +149,43 @@ func (c *Client) sendRequest(req *http.Request, v Response) error {
 }
 
 func (c *Client) sendRequestRaw(req *http.Request) (body io.ReadCloser, err error) {
-	resp, err := c.config.HTTPClient.Do(req)
+	resp, err := c.doRequest(req) //nolint:bodyclose // body is closed by the caller
 	if err != nil {
 		return
 	}
-
-	if isFailureStatusCode(resp) {
-		err = c.handleErrorResp(resp)
-		return
-	}
 	return resp.Body, nil
 }
 
-func sendRequestStream[T streamable](client *Client, req *http.Request) (*streamReader[T], error) {
+func sendRequestStream[T any](client *Client, req *http.Request, opts ...StreamOption) (*Stream[T], error) {
+	var options streamSettings
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
 
-	resp, err := client.config.HTTPClient.Do(req) //nolint:bodyclose // body is closed in stream.Close()
+	// the timeouts of the stream cancel the request
+	ctx, cancel := context.WithCancel(req.Context())
+	req = req.WithContext(ctx)
+	start := time.Now()
+	timer := newStreamTimer(options, cancel)
+
+	resp, err := client.doRequest(req) //nolint:bodyclose // body is closed in stream.Close()
 	if err != nil {
-		return new(streamReader[T]), err
-	}
-	if isFailureStatusCode(resp) {
-		return new(streamReader[T]), client.handleErrorResp(resp)
+		if timeoutErr := timer.stop(); timeoutErr != nil {
+			err = timeoutErr
+		}
+		cancel()
+		return new(Stream[T]), err
 	}
-	return &streamReader[T]{
+	timer.received()
+	return &Stream[T]{
+		timer:              timer,
+		cancel:             cancel,
+		start:              start,
 		emptyMessagesLimit: client.config.EmptyMessagesLimit,
 		reader:             bufio.NewReader(resp.Body),
 		response:           resp,
@@ -177,6 +195,62 @@ func sendRequestStream[T streamable](client *Client, req *http.Request) (*stream
 	}, nil
 }
 
+// doRequest sends req through the interceptors and retries it according to the
+// configured RetryPolicy. Failure status codes are converted into errors by
+// handleErrorResp, so a nil error always comes with a successful response whose
+// body must be closed by the caller.
+func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
+	policy := c.config.RetryPolicy
+	for attempt := 1; ; attempt++ {
+		var (
+			header     http.Header
+			statusCode int
+		)
+
+		resp, err := c.send(req)
+		c.observeRateLimits(req, resp)
+		if err == nil {
+			return resp, nil
+		}
+		if resp != nil {
+			header, statusCode = resp.Header, resp.StatusCode
+		}
+
+		if !policy.enabled() || attempt >= policy.MaxAttempts || !policy.isRetryableError(err, statusCode) {
+			return nil, err
+		}
+
+		if rewindErr := rewindRequestBody(req); rewindErr != nil {
+			return nil, err
+		}
+
+		delay, ok := retryAfter(header)
+		if !ok {
+			delay = policy.backoff(attempt)
+		}
+		if sleepErr := sleepContext(req.Context(), delay); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// rewindRequestBody resets the body of req so that it can be sent again.
+func rewindRequestBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if req.GetBody == nil {
+		return errRequestBodyNotReplayable
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
 func (c *Client) setCommonHeaders(req *http.Request) {
 	// https://learn.microsoft.com/en-us/azure/cognitive-services/openai/reference#authentication
 	// Azure API Key authentication
@@ -255,6 +329,7 @@ func (c *Client) handleErrorResp(resp *http.Response) error {
 		reqErr := &RequestError{
 			HTTPStatusCode: resp.StatusCode,
 			Err:            err,
+			Metadata:       newResponseMetadata(resp.Header),
 		}
 		if errRes.Error != nil {
 			reqErr.Err = errRes.Error
@@ -263,5 +338,6 @@ func (c *Client) handleErrorResp(resp *http.Response) error {
 	}
 
 	errRes.Error.HTTPStatusCode = resp.StatusCode
+	errRes.Error.Metadata = newResponseMetadata(resp.Header)
 	return errRes.Error
 }
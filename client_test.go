@@ -396,6 +396,21 @@ func TestClientReturnsRequestBuilderErrors(t *testing.T) {
 		{"CreateSpeech", func() (any, error) {
 			return client.CreateSpeech(ctx, CreateSpeechRequest{Model: TTSModel1, Voice: VoiceAlloy})
 		}},
+		{"CreateBatch", func() (any, error) {
+			return client.CreateBatch(ctx, CreateBatchRequest{})
+		}},
+		{"RetrieveBatch", func() (any, error) {
+			return client.RetrieveBatch(ctx, "")
+		}},
+		{"CancelBatch", func() (any, error) {
+			return client.CancelBatch(ctx, "")
+		}},
+		{"ListBatches", func() (any, error) {
+			return client.ListBatches(ctx, nil, nil)
+		}},
+		{"GetBatchResults", func() (any, error) {
+			return client.GetBatchResults(ctx, "")
+		}},
 	}
 
 	for _, testCase := range testCases {
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 )
 
 var (
@@ -110,6 +112,69 @@ var disabledModelsForEndpoints = map[string]map[string]bool{
 	},
 }
 
+// modelContextWindows are the context lengths in tokens of the models, which is the maximum
+// number of prompt and completion tokens of a request.
+var modelContextWindows = map[string]int{
+	GPT432K0613:             32768,
+	GPT432K0314:             32768,
+	GPT432K:                 32768,
+	GPT40613:                8192,
+	GPT40314:                8192,
+	GPT4Turbo0125:           128000,
+	GPT4Turbo1106:           128000,
+	GPT4TurboPreview:        128000,
+	GPT4VisionPreview:       128000,
+	GPT4:                    8192,
+	GPT3Dot5Turbo1106:       16385,
+	GPT3Dot5Turbo0613:       4096,
+	GPT3Dot5Turbo0301:       4096,
+	GPT3Dot5Turbo16K:        16385,
+	GPT3Dot5Turbo16K0613:    16385,
+	GPT3Dot5Turbo:           16385,
+	GPT3Dot5TurboInstruct:   4096,
+	GPT3TextDavinci003:      4097,
+	GPT3TextDavinci002:      4097,
+	GPT3TextCurie001:        2049,
+	GPT3TextBabbage001:      2049,
+	GPT3TextAda001:          2049,
+	GPT3TextAdaEmbeddingV2:  8191,
+	GPT3TextDavinci001:      2049,
+	GPT3DavinciInstructBeta: 2049,
+	GPT3Davinci:             2049,
+	GPT3Davinci002:          16384,
+	GPT3CurieInstructBeta:   2049,
+	GPT3Curie:               2049,
+	GPT3Curie002:            2049,
+	GPT3Ada:                 2049,
+	GPT3Ada002:              2049,
+	GPT3Babbage:             2049,
+	GPT3Babbage002:          16384,
+	CodexCodeDavinci002:     8001,
+	CodexCodeCushman001:     2048,
+	CodexCodeDavinci001:     8001,
+}
+
+// modelSnapshotSuffix matches the date or version suffixes of model snapshots, e.g. -0613 or -2024-04-09.
+var modelSnapshotSuffix = regexp.MustCompile(`^-\d{4}(-|$)`)
+
+// ModelContextWindow returns the context length of the model in tokens. Snapshots of known models,
+// named after the model followed by a date or version suffix, get the context length of the
+// longest known model name they start with. Other models aren't known.
+func ModelContextWindow(model string) (tokens int, ok bool) {
+	if tokens, ok = modelContextWindows[model]; ok {
+		return
+	}
+
+	prefix := ""
+	for name, size := range modelContextWindows {
+		if strings.HasPrefix(model, name) && len(name) > len(prefix) &&
+			modelSnapshotSuffix.MatchString(model[len(name):]) {
+			prefix, tokens, ok = name, size, true
+		}
+	}
+	return
+}
+
 func checkEndpointSupportsModel(endpoint, model string) bool {
 	return !disabledModelsForEndpoints[endpoint][model]
 }
@@ -141,6 +206,8 @@ type CompletionRequest struct {
 	// refs: https://platform.openai.com/docs/api-reference/completions/create#completions/create-logit_bias
 	LogitBias map[string]int `json:"logit_bias,omitempty"`
 	User      string         `json:"user,omitempty"`
+	// StreamOptions configures streamed completions, it's only valid when streaming.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
 }
 
 func (c CompletionRequest) Tokens() (tokens int, err error) {
@@ -211,12 +278,13 @@ func (c *Client) CreateCompletion(
 		return
 	}
 
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model),
+		withBody(request), withRateLimitModel(request.Model))
 	if err != nil {
 		return
 	}
 
-	if c.config.EnableRateLimiter {
+	if c.rateLimiter != nil {
 		err = c.rateLimiter.WaitForRequest(ctx, request.Model, request)
 		if err != nil {
 			return
@@ -36,6 +36,21 @@ type ClientConfig struct {
 
 	EmptyMessagesLimit uint
 	EnableRateLimiter  bool
+	// RateLimiter replaces the rate limiter created when EnableRateLimiter is set,
+	// e.g. with a StoreRateLimiter shared by several processes. Setting it enables rate limiting.
+	RateLimiter RateLimiter
+
+	// Interceptors wrap every request sent by the client, in order: the first
+	// interceptor is the outermost one.
+	Interceptors []Interceptor
+
+	// RetryPolicy controls retries of failed requests. Retries are disabled by default,
+	// use DefaultRetryPolicy to enable them.
+	RetryPolicy RetryPolicy
+
+	// EmbeddingCache caches the embeddings of strings created by CreateEmbeddings, only the
+	// strings missing from the cache are sent to the API. Caching is disabled by default.
+	EmbeddingCache EmbeddingCacheStore
 }
 
 func DefaultConfig(authToken string) ClientConfig {
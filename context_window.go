@@ -0,0 +1,296 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrContextWindowUnknownModel = errors.New("context window of the model is unknown, please set the context size")
+	ErrContextWindowExceeded     = errors.New("request doesn't fit into the context window")
+)
+
+const (
+	defaultSummaryMaxTokens = 256
+	defaultSummaryPrompt    = "Summarize the following conversation concisely. " +
+		"Keep names, facts, decisions and open questions which are needed to continue the conversation."
+	summaryMessagePrefix = "Summary of the earlier conversation:\n"
+)
+
+// ContextWindowStrategy defines how messages are removed from requests which don't fit into the context window.
+type ContextWindowStrategy string
+
+const (
+	// ContextWindowStrategyDropOldest drops the oldest non-system messages one by one.
+	ContextWindowStrategyDropOldest ContextWindowStrategy = "drop_oldest"
+	// ContextWindowStrategyKeepToolCalls drops the oldest non-system messages, but drops
+	// assistant messages calling tools or functions together with the results of the calls.
+	ContextWindowStrategyKeepToolCalls ContextWindowStrategy = "keep_tool_calls"
+	// ContextWindowStrategySummarize replaces the oldest non-system messages with a summary
+	// created by a chat completion. Tool calls are kept together with their results.
+	ContextWindowStrategySummarize ContextWindowStrategy = "summarize"
+)
+
+// ContextWindowManager fits chat completion requests into the context window of their model.
+// System messages and the last message are always kept.
+type ContextWindowManager struct {
+	// Strategy defaults to ContextWindowStrategyKeepToolCalls.
+	Strategy ContextWindowStrategy
+	// ReservedTokens are kept free for the completion when the request doesn't set MaxTokens.
+	ReservedTokens int
+	// Counter counts the tokens of requests.
+	Counter ChatTokenCounter
+
+	// Client creates the summaries of ContextWindowStrategySummarize.
+	Client *Client
+	// SummaryModel is the model creating the summaries, defaults to the model of the request.
+	SummaryModel string
+	// SummaryPrompt is the system prompt instructing the model to summarize a conversation.
+	SummaryPrompt string
+	// SummaryMaxTokens limits the length of the summaries, defaults to 256.
+	SummaryMaxTokens int
+}
+
+// messageGroup is a range of messages which are dropped together.
+type messageGroup struct {
+	start, end int
+	tokens     int
+	pinned     bool
+	dropped    bool
+}
+
+// Fit returns a request which fits into contextSize tokens, including the tokens reserved for the
+// completion. The context window of the model is used when contextSize is zero.
+// The given request and its messages aren't modified.
+func (m *ContextWindowManager) Fit(
+	ctx context.Context,
+	request ChatCompletionRequest,
+	contextSize int,
+) (ChatCompletionRequest, error) {
+	if contextSize <= 0 {
+		var ok bool
+		contextSize, ok = ModelContextWindow(request.Model)
+		if !ok {
+			return request, ErrContextWindowUnknownModel
+		}
+	}
+
+	budget := contextSize - request.MaxTokens
+	if request.MaxTokens == 0 {
+		budget = contextSize - m.ReservedTokens
+	}
+
+	tokens, err := m.Counter.RequestTokens(request)
+	if err != nil {
+		return request, err
+	}
+	if tokens <= budget {
+		return request, nil
+	}
+
+	groups, err := m.groupMessages(request.Model, request.Messages)
+	if err != nil {
+		return request, err
+	}
+
+	summarize := m.Strategy == ContextWindowStrategySummarize
+	summaryTokens := 0
+	if summarize {
+		summaryTokens = m.summaryMaxTokens()
+	}
+
+	tokens = dropMessageGroups(groups, tokens, budget-summaryTokens)
+
+	var summary *ChatCompletionMessage
+	if summarize {
+		summary, err = m.summarize(ctx, request, groups)
+		if err != nil {
+			return request, err
+		}
+	}
+
+	request.Messages = keptMessages(request.Messages, groups, summary)
+	tokens, err = m.Counter.RequestTokens(request)
+	if err != nil {
+		return request, err
+	}
+
+	// the summary can be longer than estimated, drop further messages to make room for it,
+	// the summary itself is a system message and is kept
+	if tokens > budget {
+		groups, err = m.groupMessages(request.Model, request.Messages)
+		if err != nil {
+			return request, err
+		}
+		tokens = dropMessageGroups(groups, tokens, budget)
+		request.Messages = keptMessages(request.Messages, groups, nil)
+	}
+
+	if tokens > budget {
+		return request, fmt.Errorf("%w: %d tokens exceed %d tokens", ErrContextWindowExceeded, tokens, budget)
+	}
+	return request, nil
+}
+
+// groupMessages splits the messages into the groups which are dropped together.
+func (m *ContextWindowManager) groupMessages(model string, messages []ChatCompletionMessage) ([]messageGroup, error) {
+	keepToolCalls := m.Strategy != ContextWindowStrategyDropOldest
+
+	groups := make([]messageGroup, 0, len(messages))
+	for i, message := range messages {
+		tokens, err := m.Counter.MessageTokens(model, message)
+		if err != nil {
+			return nil, err
+		}
+
+		isResult := message.Role == ChatMessageRoleTool || message.Role == ChatMessageRoleFunction
+		if keepToolCalls && isResult && len(groups) > 0 && isToolCallGroup(messages, groups[len(groups)-1]) {
+			groups[len(groups)-1].end = i + 1
+			groups[len(groups)-1].tokens += tokens
+			continue
+		}
+
+		groups = append(groups, messageGroup{
+			start:  i,
+			end:    i + 1,
+			tokens: tokens,
+			pinned: message.Role == ChatMessageRoleSystem,
+		})
+	}
+
+	if len(groups) > 0 {
+		groups[len(groups)-1].pinned = true
+	}
+	return groups, nil
+}
+
+// isToolCallGroup reports whether the group starts with an assistant message calling tools or functions.
+func isToolCallGroup(messages []ChatCompletionMessage, group messageGroup) bool {
+	message := messages[group.start]
+	return message.Role == ChatMessageRoleAssistant && (len(message.ToolCalls) > 0 || message.FunctionCall != nil)
+}
+
+// dropMessageGroups drops the oldest groups which aren't pinned until tokens fit into the budget
+// and returns the remaining tokens.
+func dropMessageGroups(groups []messageGroup, tokens, budget int) int {
+	for i := range groups {
+		if tokens <= budget {
+			break
+		}
+		if groups[i].pinned || groups[i].dropped {
+			continue
+		}
+		groups[i].dropped = true
+		tokens -= groups[i].tokens
+	}
+	return tokens
+}
+
+// keptMessages returns the messages of the groups which aren't dropped. The summary is inserted
+// in place of the first dropped group.
+func keptMessages(
+	messages []ChatCompletionMessage,
+	groups []messageGroup,
+	summary *ChatCompletionMessage,
+) []ChatCompletionMessage {
+	kept := make([]ChatCompletionMessage, 0, len(messages)+1)
+	for _, group := range groups {
+		if !group.dropped {
+			kept = append(kept, messages[group.start:group.end]...)
+			continue
+		}
+		if summary != nil {
+			kept = append(kept, *summary)
+			summary = nil
+		}
+	}
+	return kept
+}
+
+func (m *ContextWindowManager) summaryMaxTokens() int {
+	if m.SummaryMaxTokens > 0 {
+		return m.SummaryMaxTokens
+	}
+	return defaultSummaryMaxTokens
+}
+
+// summarize creates the summary of the dropped groups, it returns nil when no group was dropped.
+func (m *ContextWindowManager) summarize(
+	ctx context.Context,
+	request ChatCompletionRequest,
+	groups []messageGroup,
+) (*ChatCompletionMessage, error) {
+	var transcript strings.Builder
+	for _, group := range groups {
+		if !group.dropped {
+			continue
+		}
+		for _, message := range request.Messages[group.start:group.end] {
+			writeTranscriptMessage(&transcript, message)
+		}
+	}
+	if transcript.Len() == 0 {
+		return nil, nil
+	}
+
+	if m.Client == nil {
+		return nil, errors.New("summarizing messages requires a client")
+	}
+
+	model := m.SummaryModel
+	if model == "" {
+		model = request.Model
+	}
+	prompt := m.SummaryPrompt
+	if prompt == "" {
+		prompt = defaultSummaryPrompt
+	}
+
+	response, err := m.Client.CreateChatCompletion(ctx, ChatCompletionRequest{
+		Model:     model,
+		MaxTokens: m.summaryMaxTokens(),
+		Messages: []ChatCompletionMessage{
+			{Role: ChatMessageRoleSystem, Content: prompt},
+			{Role: ChatMessageRoleUser, Content: transcript.String()},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize messages: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, errors.New("failed to summarize messages: no choices returned")
+	}
+
+	return &ChatCompletionMessage{
+		Role:    ChatMessageRoleSystem,
+		Content: summaryMessagePrefix + response.Choices[0].Message.Content,
+	}, nil
+}
+
+func writeTranscriptMessage(transcript *strings.Builder, message ChatCompletionMessage) {
+	transcript.WriteString(message.Role)
+	if message.Name != "" {
+		transcript.WriteString(" (" + message.Name + ")")
+	}
+	transcript.WriteString(": ")
+
+	texts := []string{message.Content}
+	for _, part := range message.MultiContent {
+		if part.Type == ChatMessagePartTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+
+	calls := message.ToolCalls
+	if message.FunctionCall != nil {
+		calls = append(calls, ToolCall{Function: *message.FunctionCall})
+	}
+	for _, call := range calls {
+		texts = append(texts, "called "+call.Function.Name+"("+call.Function.Arguments+")")
+	}
+
+	transcript.WriteString(strings.TrimSpace(strings.Join(texts, " ")))
+	transcript.WriteString("\n")
+}
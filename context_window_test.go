@@ -0,0 +1,231 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func TestModelContextWindow(t *testing.T) {
+	testcases := []struct {
+		model      string
+		wantTokens int
+		wantOK     bool
+	}{
+		{openai.GPT4, 8192, true},
+		{openai.GPT432K0613, 32768, true},
+		{openai.GPT4TurboPreview, 128000, true},
+		{openai.GPT3Dot5Turbo0613, 4096, true},
+		{"gpt-4-32k-0125", 32768, true},
+		{"gpt-3.5-turbo-16k-2024-01-25", 16385, true},
+		{"gpt-3.5-turbo-custom", 0, false},
+		{"gpt-4o", 0, false},
+		{"gpt-4-turbo-2024-04-09", 0, false},
+		{"gpt-4-12345", 0, false},
+		{"unknown", 0, false},
+	}
+
+	for _, testcase := range testcases {
+		tokens, ok := openai.ModelContextWindow(testcase.model)
+		if tokens != testcase.wantTokens || ok != testcase.wantOK {
+			t.Errorf("ModelContextWindow(%q) = %d, %v, want %d, %v",
+				testcase.model, tokens, ok, testcase.wantTokens, testcase.wantOK)
+		}
+	}
+}
+
+func contextWindowMessages() []openai.ChatCompletionMessage {
+	call := openai.ToolCall{
+		ID:       "call_1",
+		Type:     openai.ToolTypeFunction,
+		Function: openai.FunctionCall{Name: "get_current_weather", Arguments: `{"location": "Boston, MA"}`},
+	}
+	return []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleSystem, Content: "You are a helpful assistant."},
+		{Role: openai.ChatMessageRoleUser, Content: "What's the weather like in Boston?"},
+		{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{call}},
+		{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: `{"temperature": 22, "unit": "celsius"}`},
+		{Role: openai.ChatMessageRoleAssistant, Content: "It's 22 degrees and sunny in Boston."},
+		{Role: openai.ChatMessageRoleUser, Content: "And what should I wear?"},
+	}
+}
+
+func messageRoles(messages []openai.ChatCompletionMessage) string {
+	roles := make([]string, len(messages))
+	for i, message := range messages {
+		roles[i] = message.Role
+	}
+	return strings.Join(roles, ",")
+}
+
+func TestContextWindowManagerFit(t *testing.T) {
+	messages := contextWindowMessages()
+	request := openai.ChatCompletionRequest{Model: openai.GPT4, Messages: messages, MaxTokens: 100}
+	counter := openai.ChatTokenCounter{}
+
+	total, err := request.Tokens()
+	checks.NoError(t, err, "Tokens error")
+	user, err := counter.MessageTokens(openai.GPT4, messages[1])
+	checks.NoError(t, err, "MessageTokens error")
+	assistant, err := counter.MessageTokens(openai.GPT4, messages[2])
+	checks.NoError(t, err, "MessageTokens error")
+
+	testcases := []struct {
+		name        string
+		strategy    openai.ContextWindowStrategy
+		contextSize int
+		wantRoles   string
+	}{
+		{
+			name:        "fits",
+			strategy:    openai.ContextWindowStrategyDropOldest,
+			contextSize: total + 100,
+			wantRoles:   "system,user,assistant,tool,assistant,user",
+		},
+		{
+			name:        "drop oldest",
+			strategy:    openai.ContextWindowStrategyDropOldest,
+			contextSize: total + 100 - user - assistant,
+			wantRoles:   "system,tool,assistant,user",
+		},
+		{
+			name:        "keep tool calls",
+			strategy:    openai.ContextWindowStrategyKeepToolCalls,
+			contextSize: total + 100 - user - assistant,
+			wantRoles:   "system,assistant,user",
+		},
+		{
+			name:        "default strategy",
+			contextSize: total + 100 - user - 1,
+			wantRoles:   "system,assistant,user",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			manager := &openai.ContextWindowManager{Strategy: testcase.strategy}
+			fitted, err := manager.Fit(context.Background(), request, testcase.contextSize)
+			checks.NoError(t, err, "Fit error")
+
+			if roles := messageRoles(fitted.Messages); roles != testcase.wantRoles {
+				t.Fatalf("Fit() messages = %s, want %s", roles, testcase.wantRoles)
+			}
+
+			tokens, err := fitted.Tokens()
+			checks.NoError(t, err, "Tokens error")
+			if tokens+fitted.MaxTokens > testcase.contextSize {
+				t.Fatalf("Fit() request has %d tokens, more than %d", tokens+fitted.MaxTokens, testcase.contextSize)
+			}
+		})
+	}
+
+	if messageRoles(request.Messages) != "system,user,assistant,tool,assistant,user" {
+		t.Fatalf("Fit() modified the messages of the request")
+	}
+}
+
+func TestContextWindowManagerFitErrors(t *testing.T) {
+	manager := &openai.ContextWindowManager{}
+	ctx := context.Background()
+
+	_, err := manager.Fit(ctx, openai.ChatCompletionRequest{Model: "unknown", Messages: contextWindowMessages()}, 0)
+	checks.ErrorIs(t, err, openai.ErrContextWindowUnknownModel, "Fit should fail for unknown models")
+
+	_, err = manager.Fit(ctx, openai.ChatCompletionRequest{Model: openai.GPT4, Messages: contextWindowMessages()}, 15)
+	checks.ErrorIs(t, err, openai.ErrContextWindowExceeded, "Fit should fail when pinned messages don't fit")
+
+	request := openai.ChatCompletionRequest{Model: openai.GPT4, Messages: contextWindowMessages()}
+	tokens, err := request.Tokens()
+	checks.NoError(t, err, "Tokens error")
+	manager.ReservedTokens = tokens
+	fitted, err := manager.Fit(ctx, request, 0)
+	checks.NoError(t, err, "Fit error")
+	if len(fitted.Messages) != len(request.Messages) {
+		t.Fatalf("Fit() dropped messages of a request fitting into the context window of its model")
+	}
+
+	manager.Strategy = openai.ContextWindowStrategySummarize
+	_, err = manager.Fit(ctx, request, tokens)
+	checks.ErrorContains(t, err, "requires a client", "Fit should fail to summarize without a client")
+}
+
+func TestContextWindowManagerSummarize(t *testing.T) {
+	var summaryRequest openai.ChatCompletionRequest
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		err := json.NewDecoder(r.Body).Decode(&summaryRequest)
+		checks.NoError(t, err, "Decode error")
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: "It's sunny in Boston."},
+			}},
+		})
+	})
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	config := openai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	client := openai.NewClientWithConfig(config)
+
+	messages := contextWindowMessages()
+	request := openai.ChatCompletionRequest{Model: openai.GPT4, Messages: messages}
+	// the system message and the last message are kept, there's room for a summary
+	pinned, err := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{messages[0], messages[len(messages)-1]},
+	}.Tokens()
+	checks.NoError(t, err, "Tokens error")
+	contextSize := pinned + 35
+
+	manager := &openai.ContextWindowManager{
+		Strategy:         openai.ContextWindowStrategySummarize,
+		Client:           client,
+		SummaryModel:     openai.GPT3Dot5Turbo,
+		SummaryMaxTokens: 30,
+	}
+	fitted, err := manager.Fit(context.Background(), request, contextSize)
+	checks.NoError(t, err, "Fit error")
+
+	if roles := messageRoles(fitted.Messages); roles != "system,system,user" {
+		t.Fatalf("Fit() messages = %s, want system,system,user", roles)
+	}
+	if !strings.HasSuffix(fitted.Messages[1].Content, "It's sunny in Boston.") {
+		t.Fatalf("Fit() didn't insert the summary: %q", fitted.Messages[1].Content)
+	}
+
+	if summaryRequest.Model != openai.GPT3Dot5Turbo || summaryRequest.MaxTokens != 30 {
+		t.Fatalf("unexpected summary request: %+v", summaryRequest)
+	}
+	transcript := summaryRequest.Messages[len(summaryRequest.Messages)-1].Content
+	for _, text := range []string{
+		"user: What's the weather",
+		"called get_current_weather",
+		`tool: {"temperature"`,
+		"22 degrees",
+	} {
+		if !strings.Contains(transcript, text) {
+			t.Fatalf("transcript %q doesn't contain %q", transcript, text)
+		}
+	}
+
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"error": {"message": "server error", "type": "server_error"}}`, http.StatusInternalServerError)
+	})
+	manager.Client = openai.NewClientWithConfig(config)
+	_, err = manager.Fit(context.Background(), request, contextSize)
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Fit() error = %v, want the error of the summary request", err)
+	}
+}
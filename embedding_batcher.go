@@ -0,0 +1,312 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+const (
+	defaultEmbeddingBatchMaxInputs      = 2048
+	defaultEmbeddingBatchMaxTokens      = 300000
+	defaultEmbeddingBatchMaxInputTokens = 8191
+	defaultEmbeddingBatchConcurrency    = 4
+)
+
+// EmbeddingLongInputStrategy defines how inputs longer than the input limit of the model are embedded.
+type EmbeddingLongInputStrategy string
+
+const (
+	// EmbeddingLongInputTruncate embeds the first tokens of the input.
+	EmbeddingLongInputTruncate EmbeddingLongInputStrategy = "truncate"
+	// EmbeddingLongInputAverage splits the input into pieces, embeds every piece and returns the
+	// normalized average of the embeddings of the pieces, weighted by their number of tokens.
+	EmbeddingLongInputAverage EmbeddingLongInputStrategy = "average"
+)
+
+// EmbeddingBatcher embeds any number of inputs by splitting them into requests within the limits
+// of the API. The requests are sent concurrently, under the rate limiter of the client.
+type EmbeddingBatcher struct {
+	Client *Client
+	// MaxInputs limits the number of inputs per request, defaults to 2048.
+	MaxInputs int
+	// MaxTokens limits the number of tokens per request, defaults to 300000.
+	MaxTokens int
+	// MaxInputTokens is the input limit of the model, defaults to 8191.
+	MaxInputTokens int
+	// LongInputStrategy defaults to EmbeddingLongInputTruncate.
+	LongInputStrategy EmbeddingLongInputStrategy
+	// Concurrency limits the number of concurrent requests, defaults to 4.
+	Concurrency int
+}
+
+// EmbeddingBatchResponse has an Embedding for every input, in the order of the inputs.
+// The embeddings of the inputs of failed requests have no vector.
+type EmbeddingBatchResponse struct {
+	Data  []Embedding
+	Model EmbeddingModel
+	Usage Usage
+}
+
+// EmbeddingChunkError is the error of a request embedding the inputs from Start to End (exclusive).
+type EmbeddingChunkError struct {
+	Start, End int
+	Err        error
+}
+
+func (e *EmbeddingChunkError) Error() string {
+	return fmt.Sprintf("failed to embed inputs %d to %d: %v", e.Start, e.End-1, e.Err)
+}
+
+func (e *EmbeddingChunkError) Unwrap() error {
+	return e.Err
+}
+
+// EmbeddingBatchError reports the failed requests of a batch. It unwraps to the error of the first
+// failed request.
+type EmbeddingBatchError struct {
+	Chunks   []*EmbeddingChunkError
+	Requests int
+}
+
+func (e *EmbeddingBatchError) Error() string {
+	return fmt.Sprintf("%d of %d embedding requests failed, first error: %v", len(e.Chunks), e.Requests, e.Chunks[0])
+}
+
+func (e *EmbeddingBatchError) Unwrap() error {
+	return e.Chunks[0]
+}
+
+// embeddingPiece is an input, or a piece of a long input, embedded by a request.
+type embeddingPiece struct {
+	input  int
+	text   string
+	tokens int
+}
+
+type embeddingChunk struct {
+	pieces []embeddingPiece
+	tokens int
+}
+
+// CreateEmbeddings embeds the inputs of the request. When some requests fail, the embeddings of the
+// other requests are returned with an EmbeddingBatchError.
+func (b *EmbeddingBatcher) CreateEmbeddings(
+	ctx context.Context,
+	request EmbeddingRequestStrings,
+) (response EmbeddingBatchResponse, err error) {
+	response.Model = request.Model
+	response.Data = make([]Embedding, len(request.Input))
+	for i := range response.Data {
+		response.Data[i] = Embedding{Object: "embedding", Index: i}
+	}
+
+	pieces, err := b.split(request)
+	if err != nil {
+		return
+	}
+	chunks := b.chunk(pieces)
+	vectors := make([][]float32, len(pieces))
+	chunkErrs := b.embedChunks(ctx, request, chunks, vectors, &response.Usage)
+
+	// the pieces of long inputs are consecutive
+	for start := 0; start < len(pieces); {
+		end := start + 1
+		for end < len(pieces) && pieces[end].input == pieces[start].input {
+			end++
+		}
+		response.Data[pieces[start].input].Embedding = averageEmbeddingPieces(pieces[start:end], vectors[start:end])
+		start = end
+	}
+
+	if len(chunkErrs) > 0 {
+		err = &EmbeddingBatchError{Chunks: chunkErrs, Requests: len(chunks)}
+	}
+	return
+}
+
+// split tokenizes the inputs and splits or truncates the long inputs.
+func (b *EmbeddingBatcher) split(request EmbeddingRequestStrings) ([]embeddingPiece, error) {
+	codec, err := codecForModel(request.Model.String())
+	if err != nil {
+		return nil, err
+	}
+
+	maxInputTokens := b.MaxInputTokens
+	if maxInputTokens <= 0 {
+		maxInputTokens = defaultEmbeddingBatchMaxInputTokens
+	}
+
+	pieces := make([]embeddingPiece, 0, len(request.Input))
+	for i, input := range request.Input {
+		ids, _, err := codec.Encode(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tokenize input %d: %w", i, err)
+		}
+		if len(ids) <= maxInputTokens {
+			pieces = append(pieces, embeddingPiece{input: i, text: input, tokens: len(ids)})
+			continue
+		}
+
+		for start := 0; start < len(ids); start += maxInputTokens {
+			end := start + maxInputTokens
+			if end > len(ids) {
+				end = len(ids)
+			}
+			text, err := codec.Decode(ids[start:end])
+			if err != nil {
+				return nil, fmt.Errorf("failed to split input %d: %w", i, err)
+			}
+			pieces = append(pieces, embeddingPiece{input: i, text: text, tokens: end - start})
+			if b.LongInputStrategy != EmbeddingLongInputAverage {
+				break
+			}
+		}
+	}
+	return pieces, nil
+}
+
+// chunk groups the pieces into requests within the input and token limits.
+func (b *EmbeddingBatcher) chunk(pieces []embeddingPiece) []embeddingChunk {
+	maxInputs := b.MaxInputs
+	if maxInputs <= 0 {
+		maxInputs = defaultEmbeddingBatchMaxInputs
+	}
+	maxTokens := b.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultEmbeddingBatchMaxTokens
+	}
+
+	var chunks []embeddingChunk
+	current := embeddingChunk{}
+	for _, piece := range pieces {
+		if len(current.pieces) == maxInputs ||
+			(len(current.pieces) > 0 && current.tokens+piece.tokens > maxTokens) {
+			chunks = append(chunks, current)
+			current = embeddingChunk{}
+		}
+		current.pieces = append(current.pieces, piece)
+		current.tokens += piece.tokens
+	}
+	if len(current.pieces) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// embedChunks sends the requests of the chunks concurrently and stores the embedding of every
+// piece in vectors.
+func (b *EmbeddingBatcher) embedChunks(
+	ctx context.Context,
+	request EmbeddingRequestStrings,
+	chunks []embeddingChunk,
+	vectors [][]float32,
+	usage *Usage,
+) []*EmbeddingChunkError {
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultEmbeddingBatchConcurrency
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mutex     sync.Mutex
+		chunkErrs []*EmbeddingChunkError
+	)
+	semaphore := make(chan struct{}, concurrency)
+	offset := 0
+	for _, chunk := range chunks {
+		// the semaphore is taken before starting the goroutine, so that large batches don't start
+		// a goroutine per chunk
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func(chunk embeddingChunk, offset int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			chunkUsage, err := b.embedChunk(ctx, request, chunk, vectors[offset:offset+len(chunk.pieces)])
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			usage.PromptTokens += chunkUsage.PromptTokens
+			usage.TotalTokens += chunkUsage.TotalTokens
+			if err != nil {
+				chunkErrs = append(chunkErrs, &EmbeddingChunkError{
+					Start: chunk.pieces[0].input,
+					End:   chunk.pieces[len(chunk.pieces)-1].input + 1,
+					Err:   err,
+				})
+			}
+		}(chunk, offset)
+		offset += len(chunk.pieces)
+	}
+	wg.Wait()
+
+	sort.Slice(chunkErrs, func(i, j int) bool {
+		return chunkErrs[i].Start < chunkErrs[j].Start
+	})
+	return chunkErrs
+}
+
+func (b *EmbeddingBatcher) embedChunk(
+	ctx context.Context,
+	request EmbeddingRequestStrings,
+	chunk embeddingChunk,
+	vectors [][]float32,
+) (Usage, error) {
+	request.Input = make([]string, len(chunk.pieces))
+	for i, piece := range chunk.pieces {
+		request.Input[i] = piece.text
+	}
+
+	res, err := b.Client.CreateEmbeddings(ctx, request)
+	if err != nil {
+		return Usage{}, err
+	}
+	if len(res.Data) != len(chunk.pieces) {
+		return res.Usage, fmt.Errorf("received %d embeddings for %d inputs", len(res.Data), len(chunk.pieces))
+	}
+	for _, embedding := range res.Data {
+		if embedding.Index < 0 || embedding.Index >= len(vectors) {
+			return res.Usage, fmt.Errorf("received an embedding with invalid index %d", embedding.Index)
+		}
+		vectors[embedding.Index] = embedding.Embedding
+	}
+	return res.Usage, nil
+}
+
+// averageEmbeddingPieces returns the normalized average of the embeddings of the pieces of an
+// input weighted by their tokens, or nil when a piece has no embedding.
+func averageEmbeddingPieces(pieces []embeddingPiece, vectors [][]float32) []float32 {
+	if len(vectors) == 1 {
+		return vectors[0]
+	}
+	for _, vector := range vectors {
+		if vector == nil || len(vector) != len(vectors[0]) {
+			return nil
+		}
+	}
+
+	sum := make([]float64, len(vectors[0]))
+	for i, vector := range vectors {
+		for j, value := range vector {
+			sum[j] += float64(value) * float64(pieces[i].tokens)
+		}
+	}
+	var norm float64
+	for _, value := range sum {
+		norm += value * value
+	}
+	norm = math.Sqrt(norm)
+
+	average := make([]float32, len(sum))
+	for i, value := range sum {
+		if norm > 0 {
+			value /= norm
+		}
+		average[i] = float32(value)
+	}
+	return average
+}
@@ -0,0 +1,154 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+// handleLengthEmbeddings embeds every input as the vector (length of the input, 1) and returns
+// the embeddings in reverse order. Requests with the input "fail" fail.
+func handleLengthEmbeddings(t *testing.T, inputs *[][]string) func(http.ResponseWriter, *http.Request) {
+	var mutex sync.Mutex
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request openai.EmbeddingRequestStrings
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		mutex.Lock()
+		*inputs = append(*inputs, request.Input)
+		mutex.Unlock()
+
+		response := openai.EmbeddingResponse{Usage: openai.Usage{PromptTokens: len(request.Input)}}
+		for i := len(request.Input) - 1; i >= 0; i-- {
+			if request.Input[i] == "fail" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, err = w.Write([]byte(`{"error":{"message":"invalid input","type":"invalid_request_error"}}`))
+				checks.NoError(t, err, "Write error")
+				return
+			}
+			response.Data = append(response.Data, openai.Embedding{
+				Embedding: []float32{float32(len(request.Input[i])), 1},
+				Index:     i,
+			})
+		}
+		err = json.NewEncoder(w).Encode(response)
+		checks.NoError(t, err, "Encode error")
+	}
+}
+
+func TestEmbeddingBatcher(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var inputs [][]string
+	server.RegisterHandler("/v1/embeddings", handleLengthEmbeddings(t, &inputs))
+
+	request := openai.EmbeddingRequestStrings{Model: openai.AdaEmbeddingV2}
+	for i := 0; i < 10; i++ {
+		request.Input = append(request.Input, strings.Repeat("a", i+1))
+	}
+	batcher := openai.EmbeddingBatcher{Client: client, MaxInputs: 3, Concurrency: 2}
+	response, err := batcher.CreateEmbeddings(context.Background(), request)
+	checks.NoError(t, err, "CreateEmbeddings error")
+
+	if len(inputs) != 4 || response.Usage.PromptTokens != 10 {
+		t.Fatalf("sent %d requests with %d inputs, want 4 requests with 10 inputs", len(inputs), response.Usage.PromptTokens)
+	}
+	for i, embedding := range response.Data {
+		if embedding.Index != i || embedding.Embedding[0] != float32(i+1) {
+			t.Fatalf("embedding %d = %+v, want the embedding of input %d", i, embedding, i)
+		}
+	}
+
+	// the token budget splits the inputs too, every word is a token
+	inputs = nil
+	fiveWords := "word word word word word"
+	request.Input = []string{fiveWords, fiveWords, "word"}
+	batcher = openai.EmbeddingBatcher{Client: client, MaxTokens: 8}
+	_, err = batcher.CreateEmbeddings(context.Background(), request)
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if len(inputs) != 2 || len(inputs[0])+len(inputs[1]) != 3 || len(inputs[0]) == len(inputs[1]) {
+		t.Fatalf("unexpected requests %v", inputs)
+	}
+}
+
+func TestEmbeddingBatcherLongInputs(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var inputs [][]string
+	server.RegisterHandler("/v1/embeddings", handleLengthEmbeddings(t, &inputs))
+
+	request := openai.EmbeddingRequestStrings{
+		Input: []string{"short", "word word word word word word"},
+		Model: openai.AdaEmbeddingV2,
+	}
+	batcher := openai.EmbeddingBatcher{Client: client, MaxInputTokens: 4}
+	response, err := batcher.CreateEmbeddings(context.Background(), request)
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if len(inputs[0]) != 2 || inputs[0][1] != "word word word word" {
+		t.Fatalf("the long input wasn't truncated: %q", inputs[0])
+	}
+	if response.Data[1].Embedding[0] != 19 {
+		t.Fatalf("unexpected embedding %v", response.Data[1].Embedding)
+	}
+
+	inputs = nil
+	batcher.LongInputStrategy = openai.EmbeddingLongInputAverage
+	response, err = batcher.CreateEmbeddings(context.Background(), request)
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if len(inputs[0]) != 3 || inputs[0][2] != " word word" {
+		t.Fatalf("the long input wasn't split: %q", inputs[0])
+	}
+	// (19*4 + 10*2, 1*4 + 1*2) normalized
+	vector := response.Data[1].Embedding
+	norm := math.Sqrt(96*96 + 6*6)
+	if math.Abs(float64(vector[0])-96/norm) > 1e-6 || math.Abs(float64(vector[1])-6/norm) > 1e-6 {
+		t.Fatalf("unexpected average %v", vector)
+	}
+	if response.Data[0].Embedding[0] != 5 {
+		t.Fatalf("unexpected embedding %v", response.Data[0].Embedding)
+	}
+}
+
+func TestEmbeddingBatcherPartialFailure(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var inputs [][]string
+	server.RegisterHandler("/v1/embeddings", handleLengthEmbeddings(t, &inputs))
+
+	request := openai.EmbeddingRequestStrings{
+		Input: []string{"a", "b", "fail", "c", "d"},
+		Model: openai.AdaEmbeddingV2,
+	}
+	batcher := openai.EmbeddingBatcher{Client: client, MaxInputs: 2}
+	response, err := batcher.CreateEmbeddings(context.Background(), request)
+
+	var batchErr *openai.EmbeddingBatchError
+	if !errors.As(err, &batchErr) || len(batchErr.Chunks) != 1 || batchErr.Requests != 3 {
+		t.Fatalf("CreateEmbeddings() error = %v, want an EmbeddingBatchError", err)
+	}
+	if chunk := batchErr.Chunks[0]; chunk.Start != 2 || chunk.End != 4 {
+		t.Fatalf("unexpected failed inputs %d to %d", chunk.Start, chunk.End)
+	}
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		t.Fatalf("CreateEmbeddings() error = %v, want the APIError of the request", err)
+	}
+
+	for i, embedding := range response.Data {
+		failed := i == 2 || i == 3
+		if failed != (embedding.Embedding == nil) {
+			t.Fatalf("unexpected embedding %d: %v", i, embedding.Embedding)
+		}
+	}
+	if response.Usage.PromptTokens != 3 {
+		t.Fatalf("Usage.PromptTokens = %d, want the tokens of the successful requests", response.Usage.PromptTokens)
+	}
+}
@@ -0,0 +1,247 @@
+package openai
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// EmbeddingCacheStore stores embedding vectors by key. The keys are derived from the model, the
+// dimensions and a hash of the input text of the embeddings, see EmbeddingCacheKey.
+type EmbeddingCacheStore interface {
+	// Get returns the vector stored under the key, or false when the key is missing.
+	Get(ctx context.Context, key string) ([]float32, bool, error)
+	// Set stores the vector under the key.
+	Set(ctx context.Context, key string, vector []float32) error
+}
+
+// EmbeddingCacheKey returns the key of the embedding of a text created by the model with the dimensions.
+func EmbeddingCacheKey(model EmbeddingModel, dimensions int, text string) string {
+	hash := sha256.Sum256([]byte(text))
+	// the key uses the name of the model rather than String, which maps the unknown models to a default
+	return fmt.Sprintf("%s:%d:%s", string(model), dimensions, hex.EncodeToString(hash[:]))
+}
+
+// embeddingCacheInput returns the strings of the input, or false when the input isn't made of strings.
+func embeddingCacheInput(input any) ([]string, bool) {
+	switch input := input.(type) {
+	case string:
+		return []string{input}, true
+	case []string:
+		return input, true
+	}
+	return nil, false
+}
+
+// createCachedEmbeddings looks up the embeddings of the texts in the cache and sends the missing
+// texts to the API. The usage of the response only counts the tokens of the missing texts.
+func (c *Client) createCachedEmbeddings(
+	ctx context.Context,
+	request EmbeddingRequest,
+	texts []string,
+) (response EmbeddingResponse, err error) {
+	cache := c.config.EmbeddingCache
+	response = EmbeddingResponse{
+		Object: "list",
+		Data:   make([]Embedding, len(texts)),
+		Model:  request.Model,
+	}
+
+	var misses []string
+	missIndexes := make(map[string][]int)
+	for i, text := range texts {
+		response.Data[i] = Embedding{Object: "embedding", Index: i}
+		if indexes, ok := missIndexes[text]; ok {
+			missIndexes[text] = append(indexes, i)
+			continue
+		}
+
+		vector, ok, cacheErr := cache.Get(ctx, EmbeddingCacheKey(request.Model, request.Dimensions, text))
+		if cacheErr != nil {
+			err = fmt.Errorf("failed to read embedding cache: %w", cacheErr)
+			return
+		}
+		if ok {
+			response.Data[i].Embedding = vector
+			continue
+		}
+		misses = append(misses, text)
+		missIndexes[text] = []int{i}
+	}
+	if len(misses) == 0 {
+		return
+	}
+
+	request.Input = misses
+	res, err := c.createEmbeddings(ctx, request)
+	if err != nil {
+		return
+	}
+	if len(res.Data) != len(misses) {
+		err = fmt.Errorf("received %d embeddings for %d inputs", len(res.Data), len(misses))
+		return
+	}
+
+	for _, embedding := range res.Data {
+		if embedding.Index < 0 || embedding.Index >= len(misses) {
+			err = fmt.Errorf("received an embedding with invalid index %d", embedding.Index)
+			return
+		}
+		text := misses[embedding.Index]
+		key := EmbeddingCacheKey(request.Model, request.Dimensions, text)
+		if err = cache.Set(ctx, key, embedding.Embedding); err != nil {
+			err = fmt.Errorf("failed to write embedding cache: %w", err)
+			return
+		}
+		for _, i := range missIndexes[text] {
+			response.Data[i].Embedding = embedding.Embedding
+		}
+	}
+	response.Model = res.Model
+	response.Usage = res.Usage
+	response.httpHeader = res.httpHeader
+	return
+}
+
+// MemEmbeddingCacheStore is an EmbeddingCacheStore which keeps the most recently used vectors in memory.
+type MemEmbeddingCacheStore struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memEmbeddingCacheEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewMemEmbeddingCacheStore returns a store keeping up to capacity vectors, the least recently
+// used vectors are evicted first. A capacity of zero keeps every vector.
+func NewMemEmbeddingCacheStore(capacity int) *MemEmbeddingCacheStore {
+	return &MemEmbeddingCacheStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get implements EmbeddingCacheStore.
+func (s *MemEmbeddingCacheStore) Get(_ context.Context, key string) ([]float32, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	s.order.MoveToFront(element)
+	entry, _ := element.Value.(*memEmbeddingCacheEntry)
+	return append([]float32(nil), entry.vector...), true, nil
+}
+
+// Set implements EmbeddingCacheStore.
+func (s *MemEmbeddingCacheStore) Set(_ context.Context, key string, vector []float32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// the vectors are copied, as the callers may modify them
+	vector = append([]float32(nil), vector...)
+	if element, ok := s.entries[key]; ok {
+		entry, _ := element.Value.(*memEmbeddingCacheEntry)
+		entry.vector = vector
+		s.order.MoveToFront(element)
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(&memEmbeddingCacheEntry{key: key, vector: vector})
+	for s.capacity > 0 && s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		entry, _ := s.order.Remove(oldest).(*memEmbeddingCacheEntry)
+		delete(s.entries, entry.key)
+	}
+	return nil
+}
+
+// Len returns the number of vectors in the store.
+func (s *MemEmbeddingCacheStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.order.Len()
+}
+
+// DiskEmbeddingCacheStore is an EmbeddingCacheStore which keeps every vector in a file of a directory.
+type DiskEmbeddingCacheStore struct {
+	dir string
+}
+
+// NewDiskEmbeddingCacheStore returns a store keeping the vectors in the directory, which is created
+// when it doesn't exist.
+func NewDiskEmbeddingCacheStore(dir string) (*DiskEmbeddingCacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskEmbeddingCacheStore{dir: dir}, nil
+}
+
+// path returns the path of the file of the key, the keys are hashed as they contain colons.
+func (s *DiskEmbeddingCacheStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:]))
+}
+
+// Get implements EmbeddingCacheStore.
+func (s *DiskEmbeddingCacheStore) Get(_ context.Context, key string) ([]float32, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	const sizeOfFloat32 = 4
+	if len(data)%sizeOfFloat32 != 0 {
+		return nil, false, fmt.Errorf("invalid embedding cache file of key %s", key)
+	}
+	vector := make([]float32, len(data)/sizeOfFloat32)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*sizeOfFloat32:]))
+	}
+	return vector, true, nil
+}
+
+// Set implements EmbeddingCacheStore. The file is replaced atomically, so that concurrent
+// readers never read a partial vector.
+func (s *DiskEmbeddingCacheStore) Set(_ context.Context, key string, vector []float32) error {
+	const sizeOfFloat32 = 4
+	data := make([]byte, len(vector)*sizeOfFloat32)
+	for i, value := range vector {
+		binary.LittleEndian.PutUint32(data[i*sizeOfFloat32:], math.Float32bits(value))
+	}
+
+	file, err := os.CreateTemp(s.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	// the temporary file doesn't exist anymore when it was renamed
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), s.path(key))
+}
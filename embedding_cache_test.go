@@ -0,0 +1,144 @@
+package openai_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func embeddingVectors(response openai.EmbeddingResponse) [][]float32 {
+	vectors := make([][]float32, len(response.Data))
+	for i, embedding := range response.Data {
+		vectors[i] = embedding.Embedding
+	}
+	return vectors
+}
+
+func TestEmbeddingCache(t *testing.T) {
+	client, server, teardown := setupOpenAITestServerWithConfig(func(config *openai.ClientConfig) {
+		config.EmbeddingCache = openai.NewMemEmbeddingCacheStore(0)
+	})
+	defer teardown()
+	var inputs [][]string
+	server.RegisterHandler("/v1/embeddings", handleLengthEmbeddings(t, &inputs))
+	ctx := context.Background()
+
+	response, err := client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: []string{"a", "bb", "a"},
+		Model: openai.SmallEmbedding3,
+	})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if !reflect.DeepEqual(inputs, [][]string{{"a", "bb"}}) || response.Usage.PromptTokens != 2 {
+		t.Fatalf("sent %v with usage %+v, want the distinct inputs", inputs, response.Usage)
+	}
+	if want := [][]float32{{1, 1}, {2, 1}, {1, 1}}; !reflect.DeepEqual(embeddingVectors(response), want) {
+		t.Fatalf("unexpected embeddings %v, want %v", embeddingVectors(response), want)
+	}
+
+	response, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: []string{"bb", "ccc"},
+		Model: openai.SmallEmbedding3,
+	})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if len(inputs) != 2 || !reflect.DeepEqual(inputs[1], []string{"ccc"}) || response.Usage.PromptTokens != 1 {
+		t.Fatalf("sent %v with usage %+v, want only the missing input", inputs, response.Usage)
+	}
+	if want := [][]float32{{2, 1}, {3, 1}}; !reflect.DeepEqual(embeddingVectors(response), want) {
+		t.Fatalf("unexpected embeddings %v, want %v", embeddingVectors(response), want)
+	}
+	if response.Data[1].Index != 1 {
+		t.Fatalf("unexpected index %d", response.Data[1].Index)
+	}
+
+	// cached inputs aren't sent at all
+	response, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequest{Input: "bb", Model: openai.SmallEmbedding3})
+	checks.NoError(t, err, "CreateEmbeddings error")
+	if len(inputs) != 2 || response.Usage.TotalTokens != 0 || response.Data[0].Embedding[0] != 2 {
+		t.Fatalf("unexpected response %+v after %d requests", response, len(inputs))
+	}
+
+	// the embeddings of other models and dimensions are cached under other keys
+	requests := []openai.EmbeddingRequestStrings{
+		{Input: []string{"bb"}, Model: openai.LargeEmbedding3},
+		{Input: []string{"bb"}, Model: openai.SmallEmbedding3, Dimensions: 256},
+	}
+	for _, request := range requests {
+		_, err = client.CreateEmbeddings(ctx, request)
+		checks.NoError(t, err, "CreateEmbeddings error")
+	}
+	if len(inputs) != 4 {
+		t.Fatalf("sent %d requests, want 4", len(inputs))
+	}
+
+	// the names of the models not known by the library are part of the keys too
+	for _, model := range []openai.EmbeddingModel{"azure-embed-small", "azure-embed-large"} {
+		_, err = client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{Input: []string{"bb"}, Model: model})
+		checks.NoError(t, err, "CreateEmbeddings error")
+	}
+	if len(inputs) != 6 {
+		t.Fatalf("sent %d requests, want 6", len(inputs))
+	}
+	small := openai.EmbeddingCacheKey("azure-embed-small", 0, "bb")
+	if small == openai.EmbeddingCacheKey("azure-embed-large", 0, "bb") {
+		t.Fatalf("the models share the key %s", small)
+	}
+}
+
+func TestMemEmbeddingCacheStore(t *testing.T) {
+	store := openai.NewMemEmbeddingCacheStore(2)
+	ctx := context.Background()
+	checks.NoError(t, store.Set(ctx, "a", []float32{1}), "Set error")
+	checks.NoError(t, store.Set(ctx, "b", []float32{2}), "Set error")
+	_, ok, err := store.Get(ctx, "a")
+	checks.NoError(t, err, "Get error")
+	if !ok {
+		t.Fatal("Get() didn't find the vector")
+	}
+	// b is the least recently used vector
+	checks.NoError(t, store.Set(ctx, "c", []float32{3}), "Set error")
+	if _, ok, _ = store.Get(ctx, "b"); ok || store.Len() != 2 {
+		t.Fatalf("the least recently used vector wasn't evicted, %d vectors", store.Len())
+	}
+
+	vector, _, _ := store.Get(ctx, "a")
+	vector[0] = 10
+	if vector, _, _ = store.Get(ctx, "a"); vector[0] != 1 {
+		t.Fatalf("the stored vector was modified: %v", vector)
+	}
+}
+
+func TestDiskEmbeddingCacheStore(t *testing.T) {
+	dir, cleanup := test.CreateTestDirectory(t)
+	defer cleanup()
+	store, err := openai.NewDiskEmbeddingCacheStore(filepath.Join(dir, "embeddings"))
+	checks.NoError(t, err, "NewDiskEmbeddingCacheStore error")
+	ctx := context.Background()
+
+	key := openai.EmbeddingCacheKey(openai.SmallEmbedding3, 0, "Hello")
+	_, ok, err := store.Get(ctx, key)
+	checks.NoError(t, err, "Get error")
+	if ok {
+		t.Fatal("Get() found a missing vector")
+	}
+	checks.NoError(t, store.Set(ctx, key, []float32{0.5, -1.25}), "Set error")
+
+	// another store reads the vectors of the directory
+	store, err = openai.NewDiskEmbeddingCacheStore(filepath.Join(dir, "embeddings"))
+	checks.NoError(t, err, "NewDiskEmbeddingCacheStore error")
+	vector, ok, err := store.Get(ctx, key)
+	checks.NoError(t, err, "Get error")
+	if !ok || !reflect.DeepEqual(vector, []float32{0.5, -1.25}) {
+		t.Fatalf("Get() = %v, %t, want the stored vector", vector, ok)
+	}
+	files, err := os.ReadDir(filepath.Join(dir, "embeddings"))
+	checks.NoError(t, err, "ReadDir error")
+	if len(files) != 1 {
+		t.Fatalf("the store left %d files, want 1", len(files))
+	}
+}
@@ -0,0 +1,505 @@
+package openai
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHNSWM              = 16
+	minHNSWM                  = 2
+	defaultHNSWEfConstruction = 200
+	defaultHNSWEfSearch       = 50
+)
+
+var (
+	ErrEmbeddingIndexDuplicateID = errors.New("embedding ID already exists in the index")
+	ErrEmbeddingIndexEmpty       = errors.New("embedding has no vector")
+	ErrEmbeddingIndexInvalid     = errors.New("invalid embedding index")
+)
+
+// EmbeddingIndexType is the search algorithm of an EmbeddingIndex.
+type EmbeddingIndexType string
+
+const (
+	// EmbeddingIndexBruteForce compares the query with every embedding, the results are exact.
+	EmbeddingIndexBruteForce EmbeddingIndexType = "brute_force"
+	// EmbeddingIndexHNSW searches a hierarchical navigable small world graph, the results are
+	// approximate but the search is much faster for large indexes.
+	EmbeddingIndexHNSW EmbeddingIndexType = "hnsw"
+)
+
+// EmbeddingIndexOptions configure an EmbeddingIndex. The HNSW parameters are ignored by brute force indexes.
+type EmbeddingIndexOptions struct {
+	// Type defaults to EmbeddingIndexBruteForce.
+	Type EmbeddingIndexType `json:"type"`
+	// M is the number of neighbors of the nodes of the HNSW graph, defaults to 16. Smaller values
+	// than 2 are raised to 2, as M is the base of the logarithm drawing the levels of the nodes.
+	M int `json:"m"`
+	// EfConstruction is the number of candidates considered when adding embeddings, defaults to 200.
+	EfConstruction int `json:"ef_construction"`
+	// EfSearch is the number of candidates considered when searching, defaults to 50.
+	// Larger values return better results slower.
+	EfSearch int `json:"ef_search"`
+}
+
+// IndexedEmbedding is an embedding stored in an EmbeddingIndex.
+type IndexedEmbedding struct {
+	ID string `json:"id"`
+	// Embedding is the normalized embedding vector.
+	Embedding []float32         `json:"embedding"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// EmbeddingMatch is a result of a search, Score is the cosine similarity with the query.
+type EmbeddingMatch struct {
+	IndexedEmbedding
+	Score float32
+}
+
+// EmbeddingIndex is an in-memory index answering top-k similarity queries over embeddings, ranked
+// by cosine similarity. It's safe for concurrent use.
+type EmbeddingIndex struct {
+	mutex   sync.RWMutex
+	options EmbeddingIndexOptions
+	entries []IndexedEmbedding
+	ids     map[string]int
+	graph   *hnswGraph
+}
+
+func NewEmbeddingIndex(options EmbeddingIndexOptions) *EmbeddingIndex {
+	if options.Type == "" {
+		options.Type = EmbeddingIndexBruteForce
+	}
+	if options.M <= 0 {
+		options.M = defaultHNSWM
+	} else if options.M < minHNSWM {
+		options.M = minHNSWM
+	}
+	if options.EfConstruction <= 0 {
+		options.EfConstruction = defaultHNSWEfConstruction
+	}
+	if options.EfSearch <= 0 {
+		options.EfSearch = defaultHNSWEfSearch
+	}
+
+	index := &EmbeddingIndex{
+		options: options,
+		ids:     make(map[string]int),
+	}
+	if options.Type == EmbeddingIndexHNSW {
+		index.graph = newHNSWGraph()
+	}
+	return index
+}
+
+// Add adds an embedding to the index. The embedding vector is copied and normalized.
+func (i *EmbeddingIndex) Add(id string, embedding Embedding, metadata map[string]string) error {
+	if len(embedding.Embedding) == 0 {
+		return ErrEmbeddingIndexEmpty
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if _, ok := i.ids[id]; ok {
+		return fmt.Errorf("%w: %s", ErrEmbeddingIndexDuplicateID, id)
+	}
+	if len(i.entries) > 0 && len(i.entries[0].Embedding) != len(embedding.Embedding) {
+		return ErrVectorLengthMismatch
+	}
+
+	vector := Embedding{Embedding: append([]float32(nil), embedding.Embedding...)}
+	vector.Normalize()
+	i.ids[id] = len(i.entries)
+	i.entries = append(i.entries, IndexedEmbedding{ID: id, Embedding: vector.Embedding, Metadata: metadata})
+	if i.graph != nil {
+		i.graph.insert(len(i.entries)-1, i.vector, i.options)
+	}
+	return nil
+}
+
+// Get returns the embedding with the ID.
+func (i *EmbeddingIndex) Get(id string) (IndexedEmbedding, bool) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	node, ok := i.ids[id]
+	if !ok {
+		return IndexedEmbedding{}, false
+	}
+	return i.entries[node], true
+}
+
+// Len returns the number of embeddings in the index.
+func (i *EmbeddingIndex) Len() int {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return len(i.entries)
+}
+
+// Search returns the k embeddings most similar to the query, the most similar first.
+func (i *EmbeddingIndex) Search(query Embedding, k int) ([]EmbeddingMatch, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if len(i.entries) == 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query.Embedding) != len(i.entries[0].Embedding) {
+		return nil, ErrVectorLengthMismatch
+	}
+
+	query = Embedding{Embedding: append([]float32(nil), query.Embedding...)}
+	query.Normalize()
+	var nodes []hnswCandidate
+	if i.graph != nil {
+		ef := i.options.EfSearch
+		if ef < k {
+			ef = k
+		}
+		nodes = i.graph.search(query.Embedding, ef, i.vector)
+	} else {
+		nodes = i.bruteForce(query.Embedding)
+	}
+
+	if len(nodes) > k {
+		nodes = nodes[:k]
+	}
+	matches := make([]EmbeddingMatch, len(nodes))
+	for j, node := range nodes {
+		matches[j] = EmbeddingMatch{IndexedEmbedding: i.entries[node.node], Score: 1 - node.distance}
+	}
+	return matches, nil
+}
+
+func (i *EmbeddingIndex) bruteForce(query []float32) []hnswCandidate {
+	candidates := make([]hnswCandidate, len(i.entries))
+	for node, entry := range i.entries {
+		candidates[node] = hnswCandidate{node: node, distance: cosineDistance(query, entry.Embedding)}
+	}
+	sort.Slice(candidates, func(a, b int) bool {
+		return candidates[a].distance < candidates[b].distance
+	})
+	return candidates
+}
+
+func (i *EmbeddingIndex) vector(node int) []float32 {
+	return i.entries[node].Embedding
+}
+
+// cosineDistance is the cosine distance between normalized vectors.
+func cosineDistance(a, b []float32) float32 {
+	var dotProduct float32
+	for i := range a {
+		dotProduct += a[i] * b[i]
+	}
+	return 1 - dotProduct
+}
+
+// embeddingIndexFile is the JSON encoding of an EmbeddingIndex.
+type embeddingIndexFile struct {
+	Options EmbeddingIndexOptions `json:"options"`
+	Entries []IndexedEmbedding    `json:"entries"`
+	Graph   *hnswGraph            `json:"graph,omitempty"`
+}
+
+// Save writes the index as JSON, including the HNSW graph.
+func (i *EmbeddingIndex) Save(w io.Writer) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	return json.NewEncoder(w).Encode(embeddingIndexFile{
+		Options: i.options,
+		Entries: i.entries,
+		Graph:   i.graph,
+	})
+}
+
+// SaveFile writes the index to a file.
+func (i *EmbeddingIndex) SaveFile(path string) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	return i.Save(file)
+}
+
+// LoadEmbeddingIndex reads an index written by Save. Indexes which can't have been written by Save
+// are rejected with ErrEmbeddingIndexInvalid.
+func LoadEmbeddingIndex(r io.Reader) (*EmbeddingIndex, error) {
+	var file embeddingIndexFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding index: %w", err)
+	}
+
+	index := NewEmbeddingIndex(file.Options)
+	if index.options.Type != EmbeddingIndexBruteForce && index.options.Type != EmbeddingIndexHNSW {
+		return nil, fmt.Errorf("%w: unknown type %s", ErrEmbeddingIndexInvalid, index.options.Type)
+	}
+	for node, entry := range file.Entries {
+		if len(entry.Embedding) == 0 || len(entry.Embedding) != len(file.Entries[0].Embedding) {
+			return nil, fmt.Errorf("%w: embedding %s has %d dimensions, want %d",
+				ErrEmbeddingIndexInvalid, entry.ID, len(entry.Embedding), len(file.Entries[0].Embedding))
+		}
+		if _, ok := index.ids[entry.ID]; ok {
+			return nil, fmt.Errorf("%w: duplicate ID %s", ErrEmbeddingIndexInvalid, entry.ID)
+		}
+		index.ids[entry.ID] = node
+	}
+	index.entries = file.Entries
+
+	if index.graph != nil {
+		if file.Graph == nil {
+			return nil, fmt.Errorf("%w: missing HNSW graph", ErrEmbeddingIndexInvalid)
+		}
+		if err := file.Graph.validate(len(file.Entries)); err != nil {
+			return nil, fmt.Errorf("%w: invalid HNSW graph: %v", ErrEmbeddingIndexInvalid, err)
+		}
+		index.graph = file.Graph
+		index.graph.random = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec // not used for security
+	}
+	return index, nil
+}
+
+// LoadEmbeddingIndexFile reads an index from a file written by SaveFile.
+func LoadEmbeddingIndexFile(path string) (*EmbeddingIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadEmbeddingIndex(file)
+}
+
+// hnswGraph is a hierarchical navigable small world graph, see https://arxiv.org/abs/1603.09320.
+type hnswGraph struct {
+	EntryPoint int `json:"entry_point"`
+	MaxLevel   int `json:"max_level"`
+	// Levels is the top level of every node.
+	Levels []int `json:"levels"`
+	// Neighbors are the neighbors of every node on each of its levels.
+	Neighbors [][][]int `json:"neighbors"`
+
+	random *rand.Rand
+}
+
+type hnswCandidate struct {
+	node     int
+	distance float32
+}
+
+func newHNSWGraph() *hnswGraph {
+	return &hnswGraph{
+		EntryPoint: -1,
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec // not used for security
+	}
+}
+
+// validate checks that the graph of n nodes can be searched without indexing out of range.
+func (g *hnswGraph) validate(n int) error {
+	if len(g.Levels) != n || len(g.Neighbors) != n {
+		return fmt.Errorf("%d levels and %d neighbor lists for %d nodes", len(g.Levels), len(g.Neighbors), n)
+	}
+	if n == 0 {
+		if g.EntryPoint != -1 {
+			return fmt.Errorf("entry point %d of an empty graph", g.EntryPoint)
+		}
+		return nil
+	}
+	if g.EntryPoint < 0 || g.EntryPoint >= n || g.Levels[g.EntryPoint] != g.MaxLevel {
+		return fmt.Errorf("invalid entry point %d", g.EntryPoint)
+	}
+
+	for node := range g.Levels {
+		if err := g.validateNode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *hnswGraph) validateNode(node int) error {
+	level := g.Levels[node]
+	if level < 0 || level > g.MaxLevel || len(g.Neighbors[node]) != level+1 {
+		return fmt.Errorf("node %d has level %d and %d neighbor levels", node, level, len(g.Neighbors[node]))
+	}
+	for l, neighbors := range g.Neighbors[node] {
+		for _, neighbor := range neighbors {
+			// the neighbors of a level are searched on the same level
+			if neighbor < 0 || neighbor >= len(g.Levels) || g.Levels[neighbor] < l {
+				return fmt.Errorf("node %d has invalid neighbor %d on level %d", node, neighbor, l)
+			}
+		}
+	}
+	return nil
+}
+
+// insert adds the node to the graph, vectors returns the vectors of the nodes.
+func (g *hnswGraph) insert(node int, vectors func(int) []float32, options EmbeddingIndexOptions) {
+	level := int(math.Floor(-math.Log(1-g.random.Float64()) / math.Log(float64(options.M))))
+	g.Levels = append(g.Levels, level)
+	g.Neighbors = append(g.Neighbors, make([][]int, level+1))
+	if g.EntryPoint < 0 {
+		g.EntryPoint, g.MaxLevel = node, level
+		return
+	}
+
+	query := vectors(node)
+	entry := []hnswCandidate{{node: g.EntryPoint, distance: cosineDistance(query, vectors(g.EntryPoint))}}
+	for l := g.MaxLevel; l > level; l-- {
+		entry = g.searchLayer(query, entry, 1, l, vectors)
+	}
+
+	top := level
+	if top > g.MaxLevel {
+		top = g.MaxLevel
+	}
+	for l := top; l >= 0; l-- {
+		candidates := g.searchLayer(query, entry, options.EfConstruction, l, vectors)
+		maxNeighbors := options.M
+		if l == 0 {
+			maxNeighbors *= 2
+		}
+
+		neighbors := candidates
+		if len(neighbors) > options.M {
+			neighbors = neighbors[:options.M]
+		}
+		for _, neighbor := range neighbors {
+			g.Neighbors[node][l] = append(g.Neighbors[node][l], neighbor.node)
+			g.connect(neighbor.node, node, l, maxNeighbors, vectors)
+		}
+		entry = candidates
+	}
+
+	if level > g.MaxLevel {
+		g.EntryPoint, g.MaxLevel = node, level
+	}
+}
+
+// connect adds the node to the neighbors of another node and keeps the closest neighbors.
+func (g *hnswGraph) connect(other, node, level, maxNeighbors int, vectors func(int) []float32) {
+	g.Neighbors[other][level] = append(g.Neighbors[other][level], node)
+	neighbors := g.Neighbors[other][level]
+	if len(neighbors) > maxNeighbors {
+		vector := vectors(other)
+		sort.Slice(neighbors, func(a, b int) bool {
+			return cosineDistance(vector, vectors(neighbors[a])) < cosineDistance(vector, vectors(neighbors[b]))
+		})
+		g.Neighbors[other][level] = neighbors[:maxNeighbors]
+	}
+}
+
+// search returns up to ef nodes close to the query, the closest first.
+func (g *hnswGraph) search(query []float32, ef int, vectors func(int) []float32) []hnswCandidate {
+	if g.EntryPoint < 0 {
+		return nil
+	}
+
+	entry := []hnswCandidate{{node: g.EntryPoint, distance: cosineDistance(query, vectors(g.EntryPoint))}}
+	for l := g.MaxLevel; l > 0; l-- {
+		entry = g.searchLayer(query, entry, 1, l, vectors)
+	}
+	return g.searchLayer(query, entry, ef, 0, vectors)
+}
+
+// searchLayer returns up to ef nodes of the level close to the query, the closest first.
+func (g *hnswGraph) searchLayer(
+	query []float32,
+	entry []hnswCandidate,
+	ef, level int,
+	vectors func(int) []float32,
+) []hnswCandidate {
+	visited := make(map[int]bool, ef)
+	candidates := &hnswHeap{}
+	results := &hnswHeap{farthestFirst: true}
+	for _, candidate := range entry {
+		visited[candidate.node] = true
+		heap.Push(candidates, candidate)
+		results.pushBounded(candidate, ef)
+	}
+
+	for candidates.Len() > 0 {
+		current, _ := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && current.distance > results.items[0].distance {
+			break
+		}
+
+		for _, neighbor := range g.Neighbors[current.node][level] {
+			if visited[neighbor] {
+				continue
+			}
+			visited[neighbor] = true
+
+			candidate := hnswCandidate{node: neighbor, distance: cosineDistance(query, vectors(neighbor))}
+			if results.Len() < ef || candidate.distance < results.items[0].distance {
+				heap.Push(candidates, candidate)
+				results.pushBounded(candidate, ef)
+			}
+		}
+	}
+
+	closest := make([]hnswCandidate, results.Len())
+	for i := len(closest) - 1; i >= 0; i-- {
+		closest[i], _ = heap.Pop(results).(hnswCandidate)
+	}
+	return closest
+}
+
+// hnswHeap is a heap of candidates, the closest or the farthest first.
+type hnswHeap struct {
+	items         []hnswCandidate
+	farthestFirst bool
+}
+
+// pushBounded pushes the candidate and pops the first candidate when the heap has more than n candidates.
+func (h *hnswHeap) pushBounded(candidate hnswCandidate, n int) {
+	heap.Push(h, candidate)
+	if h.Len() > n {
+		heap.Pop(h)
+	}
+}
+
+func (h *hnswHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *hnswHeap) Less(i, j int) bool {
+	if h.farthestFirst {
+		return h.items[i].distance > h.items[j].distance
+	}
+	return h.items[i].distance < h.items[j].distance
+}
+
+func (h *hnswHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *hnswHeap) Push(x any) {
+	candidate, _ := x.(hnswCandidate)
+	h.items = append(h.items, candidate)
+}
+
+func (h *hnswHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,189 @@
+package openai_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func randomEmbedding(random *rand.Rand, dimensions int) openai.Embedding {
+	embedding := openai.Embedding{Embedding: make([]float32, dimensions)}
+	for i := range embedding.Embedding {
+		embedding.Embedding[i] = float32(random.NormFloat64())
+	}
+	return embedding
+}
+
+func TestEmbeddingIndexBruteForce(t *testing.T) {
+	index := openai.NewEmbeddingIndex(openai.EmbeddingIndexOptions{})
+	err := index.Add("x", openai.Embedding{Embedding: []float32{2, 0}}, map[string]string{"axis": "x"})
+	checks.NoError(t, err, "Add error")
+	err = index.Add("y", openai.Embedding{Embedding: []float32{0, 3}}, map[string]string{"axis": "y"})
+	checks.NoError(t, err, "Add error")
+	err = index.Add("xy", openai.Embedding{Embedding: []float32{1, 1}}, nil)
+	checks.NoError(t, err, "Add error")
+
+	matches, err := index.Search(openai.Embedding{Embedding: []float32{1, 0.1}}, 2)
+	checks.NoError(t, err, "Search error")
+	if len(matches) != 2 || matches[0].ID != "x" || matches[1].ID != "xy" || matches[0].Metadata["axis"] != "x" {
+		t.Fatalf("unexpected matches %+v", matches)
+	}
+	if matches[0].Score < matches[1].Score || matches[0].Score > 1 {
+		t.Fatalf("unexpected scores %v and %v", matches[0].Score, matches[1].Score)
+	}
+	if entry, ok := index.Get("x"); !ok || entry.Embedding[0] != 1 {
+		t.Fatalf("Get() = %+v, %t, want the normalized embedding", entry, ok)
+	}
+
+	err = index.Add("x", openai.Embedding{Embedding: []float32{1, 0}}, nil)
+	checks.ErrorIs(t, err, openai.ErrEmbeddingIndexDuplicateID, "duplicate IDs should be rejected")
+	err = index.Add("z", openai.Embedding{Embedding: []float32{1, 0, 0}}, nil)
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "embeddings of other lengths should be rejected")
+	_, err = index.Search(openai.Embedding{Embedding: []float32{1}}, 1)
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "queries of other lengths should be rejected")
+	if index.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", index.Len())
+	}
+}
+
+func TestEmbeddingIndexHNSW(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	exact := openai.NewEmbeddingIndex(openai.EmbeddingIndexOptions{})
+	approximate := openai.NewEmbeddingIndex(openai.EmbeddingIndexOptions{Type: openai.EmbeddingIndexHNSW, M: 8})
+	for i := 0; i < 1000; i++ {
+		embedding := randomEmbedding(random, 16)
+		checks.NoError(t, exact.Add(fmt.Sprint(i), embedding, nil), "Add error")
+		checks.NoError(t, approximate.Add(fmt.Sprint(i), embedding, nil), "Add error")
+	}
+
+	found, total := 0, 0
+	for i := 0; i < 20; i++ {
+		query := randomEmbedding(random, 16)
+		want, err := exact.Search(query, 10)
+		checks.NoError(t, err, "Search error")
+		got, err := approximate.Search(query, 10)
+		checks.NoError(t, err, "Search error")
+
+		ids := make(map[string]bool)
+		for _, match := range got {
+			ids[match.ID] = true
+		}
+		for _, match := range want {
+			if ids[match.ID] {
+				found++
+			}
+			total++
+		}
+	}
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Fatalf("the recall of the HNSW index is %.2f, want at least 0.9", recall)
+	}
+}
+
+func TestEmbeddingIndexPersistence(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, indexType := range []openai.EmbeddingIndexType{openai.EmbeddingIndexBruteForce, openai.EmbeddingIndexHNSW} {
+		index := openai.NewEmbeddingIndex(openai.EmbeddingIndexOptions{Type: indexType})
+		for i := 0; i < 100; i++ {
+			err := index.Add(fmt.Sprint(i), randomEmbedding(random, 8), map[string]string{"n": fmt.Sprint(i)})
+			checks.NoError(t, err, "Add error")
+		}
+
+		dir, cleanup := test.CreateTestDirectory(t)
+		path := filepath.Join(dir, "index.json")
+		checks.NoError(t, index.SaveFile(path), "SaveFile error")
+		loaded, err := openai.LoadEmbeddingIndexFile(path)
+		checks.NoError(t, err, "LoadEmbeddingIndexFile error")
+		cleanup()
+
+		query := randomEmbedding(random, 8)
+		want, err := index.Search(query, 5)
+		checks.NoError(t, err, "Search error")
+		got, err := loaded.Search(query, 5)
+		checks.NoError(t, err, "Search error")
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("the loaded %s index returned %v, want %v", indexType, got, want)
+		}
+
+		// the loaded index accepts new embeddings
+		checks.NoError(t, loaded.Add("new", randomEmbedding(random, 8), nil), "Add error")
+		if loaded.Len() != 101 {
+			t.Fatalf("Len() = %d, want 101", loaded.Len())
+		}
+	}
+
+}
+
+func TestLoadInvalidEmbeddingIndex(t *testing.T) {
+	entries := `[{"id":"a","embedding":[1,0]},{"id":"b","embedding":[0,1]}]`
+	graph := `{"entry_point":0,"max_level":1,"levels":[1,0],"neighbors":[[[1],[]],[[0]]]}`
+	_, err := openai.LoadEmbeddingIndex(bytes.NewReader([]byte(
+		`{"options":{"type":"hnsw"},"entries":` + entries + `,"graph":` + graph + `}`,
+	)))
+	checks.NoError(t, err, "LoadEmbeddingIndex error")
+
+	testcases := []struct {
+		name    string
+		options string
+		entries string
+		graph   string
+	}{
+		{"unknown type", `{"type":"kd_tree"}`, entries, ""},
+		{"duplicate ID", `{}`, `[{"id":"a","embedding":[1,0]},{"id":"a","embedding":[0,1]}]`, ""},
+		{"vector length mismatch", `{}`, `[{"id":"a","embedding":[1,0]},{"id":"b","embedding":[0,1,0]}]`, ""},
+		{"empty vector", `{}`, `[{"id":"a","embedding":[]}]`, ""},
+		{"missing graph", `{"type":"hnsw"}`, entries, ""},
+		{
+			"missing neighbors", `{"type":"hnsw"}`, entries,
+			`{"entry_point":0,"max_level":1,"levels":[1,0],"neighbors":[[[1],[]]]}`,
+		},
+		{
+			"missing neighbor level", `{"type":"hnsw"}`, entries,
+			`{"entry_point":0,"max_level":1,"levels":[1,0],"neighbors":[[[1]],[[0]]]}`,
+		},
+		{
+			"neighbor out of range", `{"type":"hnsw"}`, entries,
+			`{"entry_point":0,"max_level":1,"levels":[1,0],"neighbors":[[[2],[]],[[0]]]}`,
+		},
+		{
+			"neighbor below the level", `{"type":"hnsw"}`, entries,
+			`{"entry_point":0,"max_level":1,"levels":[1,0],"neighbors":[[[1],[1]],[[0]]]}`,
+		},
+		{
+			"entry point out of range", `{"type":"hnsw"}`, entries,
+			`{"entry_point":2,"max_level":1,"levels":[1,0],"neighbors":[[[1],[]],[[0]]]}`,
+		},
+		{
+			"entry point below the top level", `{"type":"hnsw"}`, entries,
+			`{"entry_point":1,"max_level":1,"levels":[1,0],"neighbors":[[[1],[]],[[0]]]}`,
+		},
+		{"entry point of an empty graph", `{"type":"hnsw"}`, `[]`, `{"entry_point":0,"levels":[],"neighbors":[]}`},
+	}
+	for _, testcase := range testcases {
+		data := `{"options":` + testcase.options + `,"entries":` + testcase.entries
+		if testcase.graph != "" {
+			data += `,"graph":` + testcase.graph
+		}
+		_, err = openai.LoadEmbeddingIndex(bytes.NewReader([]byte(data + "}")))
+		checks.ErrorIs(t, err, openai.ErrEmbeddingIndexInvalid, testcase.name+" should be rejected")
+	}
+}
+
+func TestEmbeddingIndexSmallM(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	index := openai.NewEmbeddingIndex(openai.EmbeddingIndexOptions{Type: openai.EmbeddingIndexHNSW, M: 1})
+	for i := 0; i < 100; i++ {
+		checks.NoError(t, index.Add(fmt.Sprint(i), randomEmbedding(random, 4), nil), "Add error")
+	}
+	matches, err := index.Search(randomEmbedding(random, 4), 5)
+	checks.NoError(t, err, "Search error")
+	if len(matches) != 5 {
+		t.Fatalf("Search() returned %d matches, want 5", len(matches))
+	}
+}
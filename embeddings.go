@@ -10,7 +10,11 @@ import (
 	"net/http"
 )
 
-var ErrVectorLengthMismatch = errors.New("vector length mismatch")
+var (
+	ErrVectorLengthMismatch       = errors.New("vector length mismatch")
+	ErrEmbeddingInvalidDimensions = errors.New("invalid number of embedding dimensions")
+	ErrNoEmbeddings               = errors.New("no embeddings")
+)
 
 // EmbeddingModel enumerates the models which can be used
 // to generate Embedding vectors.
@@ -69,6 +73,94 @@ func (e *Embedding) DotProduct(other *Embedding) (float32, error) {
 	return dotProduct, nil
 }
 
+// CosineSimilarity calculates the cosine of the angle between the embedding vector and another
+// embedding vector, from -1 to 1. Vectors with a length of zero have a similarity of 0.
+func (e *Embedding) CosineSimilarity(other *Embedding) (float32, error) {
+	if len(e.Embedding) != len(other.Embedding) {
+		return 0, ErrVectorLengthMismatch
+	}
+
+	var dotProduct, norm, otherNorm float64
+	for i := range e.Embedding {
+		dotProduct += float64(e.Embedding[i]) * float64(other.Embedding[i])
+		norm += float64(e.Embedding[i]) * float64(e.Embedding[i])
+		otherNorm += float64(other.Embedding[i]) * float64(other.Embedding[i])
+	}
+	if norm == 0 || otherNorm == 0 {
+		return 0, nil
+	}
+
+	return float32(dotProduct / math.Sqrt(norm*otherNorm)), nil
+}
+
+// L2Distance calculates the euclidean distance between the embedding vector and another embedding vector.
+func (e *Embedding) L2Distance(other *Embedding) (float32, error) {
+	if len(e.Embedding) != len(other.Embedding) {
+		return 0, ErrVectorLengthMismatch
+	}
+
+	var sum float64
+	for i := range e.Embedding {
+		diff := float64(e.Embedding[i]) - float64(other.Embedding[i])
+		sum += diff * diff
+	}
+
+	return float32(math.Sqrt(sum)), nil
+}
+
+// Normalize scales the embedding vector to unit length. Vectors with a length of zero are kept.
+func (e *Embedding) Normalize() {
+	var norm float64
+	for _, value := range e.Embedding {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 {
+		return
+	}
+
+	norm = math.Sqrt(norm)
+	for i, value := range e.Embedding {
+		e.Embedding[i] = float32(float64(value) / norm)
+	}
+}
+
+// MeanPooling returns the element-wise mean of the embedding vectors, e.g. to embed a document
+// from the embeddings of its chunks. The mean isn't normalized.
+func MeanPooling(embeddings []Embedding) (Embedding, error) {
+	if len(embeddings) == 0 {
+		return Embedding{}, ErrNoEmbeddings
+	}
+
+	sum := make([]float64, len(embeddings[0].Embedding))
+	for _, embedding := range embeddings {
+		if len(embedding.Embedding) != len(sum) {
+			return Embedding{}, ErrVectorLengthMismatch
+		}
+		for i, value := range embedding.Embedding {
+			sum[i] += float64(value)
+		}
+	}
+
+	mean := make([]float32, len(sum))
+	for i, value := range sum {
+		mean[i] = float32(value / float64(len(embeddings)))
+	}
+	return Embedding{Object: "embedding", Embedding: mean}, nil
+}
+
+// Truncate shortens the embedding to its first dimensions and normalizes it to unit length.
+// The embeddings of the text-embedding-3 models can be shortened like this without losing their
+// concept-representing properties, as with the Dimensions of the request.
+func (e *Embedding) Truncate(dimensions int) error {
+	if dimensions <= 0 || dimensions > len(e.Embedding) {
+		return fmt.Errorf("%w: %d of %d", ErrEmbeddingInvalidDimensions, dimensions, len(e.Embedding))
+	}
+
+	e.Embedding = append([]float32(nil), e.Embedding[:dimensions]...)
+	e.Normalize()
+	return nil
+}
+
 // EmbeddingResponse is the response from a Create embeddings request.
 type EmbeddingResponse struct {
 	Object string         `json:"object"`
@@ -79,6 +171,16 @@ type EmbeddingResponse struct {
 	httpHeader
 }
 
+// Truncate shortens all the embeddings of the response, see Embedding.Truncate.
+func (r *EmbeddingResponse) Truncate(dimensions int) error {
+	for i := range r.Data {
+		if err := r.Data[i].Truncate(dimensions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type base64String string
 
 func (b base64String) Decode() ([]float32, error) {
@@ -158,6 +260,7 @@ type EmbeddingRequest struct {
 	Model          EmbeddingModel          `json:"model"`
 	User           string                  `json:"user"`
 	EncodingFormat EmbeddingEncodingFormat `json:"encoding_format,omitempty"`
+	Dimensions     int                     `json:"dimensions,omitempty"`
 }
 
 func (r EmbeddingRequest) Convert() EmbeddingRequest {
@@ -182,25 +285,37 @@ type EmbeddingRequestStrings struct {
 	// Currently, only "float" and "base64" are supported, however, "base64" is not officially documented.
 	// If not specified OpenAI will use "float".
 	EncodingFormat EmbeddingEncodingFormat `json:"encoding_format,omitempty"`
+	// Dimensions is the number of dimensions of the embeddings. Only supported by the text-embedding-3
+	// and later models.
+	Dimensions int `json:"dimensions,omitempty"`
 }
 
+// Tokens counts the tokens of the inputs, which are a string, a slice of strings or a slice of
+// slices of tokens.
 func (r EmbeddingRequest) Tokens() (tokens int, err error) {
-	var text = ""
-	if r.Input != nil {
-		if paragraphs, ok := r.Input.([]string); ok {
-			for _, paragraph := range paragraphs {
-				text += paragraph
-			}
+	var texts []string
+	switch input := r.Input.(type) {
+	case string:
+		texts = []string{input}
+	case []string:
+		texts = input
+	case [][]int:
+		for _, ids := range input {
+			tokens += len(ids)
 		}
-	}
-
-	ids, _, err := Tokenize(r.Model.String(), text)
-	if err != nil {
-		err = fmt.Errorf("failed to tokenize prompt: %w", err)
 		return
 	}
 
-	return len(ids), nil
+	for _, text := range texts {
+		var ids []uint
+		ids, _, err = Tokenize(r.Model.String(), text)
+		if err != nil {
+			err = fmt.Errorf("failed to tokenize prompt: %w", err)
+			return 0, err
+		}
+		tokens += len(ids)
+	}
+	return tokens, nil
 }
 
 func (r EmbeddingRequestStrings) Convert() EmbeddingRequest {
@@ -209,6 +324,7 @@ func (r EmbeddingRequestStrings) Convert() EmbeddingRequest {
 		Model:          r.Model,
 		User:           r.User,
 		EncodingFormat: r.EncodingFormat,
+		Dimensions:     r.Dimensions,
 	}
 }
 
@@ -229,6 +345,9 @@ type EmbeddingRequestTokens struct {
 	// Currently, only "float" and "base64" are supported, however, "base64" is not officially documented.
 	// If not specified OpenAI will use "float".
 	EncodingFormat EmbeddingEncodingFormat `json:"encoding_format,omitempty"`
+	// Dimensions is the number of dimensions of the embeddings. Only supported by the text-embedding-3
+	// and later models.
+	Dimensions int `json:"dimensions,omitempty"`
 }
 
 func (r EmbeddingRequestTokens) Convert() EmbeddingRequest {
@@ -237,6 +356,7 @@ func (r EmbeddingRequestTokens) Convert() EmbeddingRequest {
 		Model:          r.Model,
 		User:           r.User,
 		EncodingFormat: r.EncodingFormat,
+		Dimensions:     r.Dimensions,
 	}
 }
 
@@ -244,18 +364,29 @@ func (r EmbeddingRequestTokens) Convert() EmbeddingRequest {
 // https://beta.openai.com/docs/api-reference/embeddings/create
 //
 // Body should be of type EmbeddingRequestStrings for embedding strings or EmbeddingRequestTokens
-// for embedding groups of text already converted to tokens.
+// for embedding groups of text already converted to tokens. The embeddings of strings are cached
+// when the EmbeddingCache of the config is set.
 func (c *Client) CreateEmbeddings(
 	ctx context.Context,
 	conv EmbeddingRequestConverter,
 ) (res EmbeddingResponse, err error) {
 	baseReq := conv.Convert()
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/embeddings", string(baseReq.Model)), withBody(baseReq))
+	if c.config.EmbeddingCache != nil {
+		if texts, ok := embeddingCacheInput(baseReq.Input); ok {
+			return c.createCachedEmbeddings(ctx, baseReq, texts)
+		}
+	}
+	return c.createEmbeddings(ctx, baseReq)
+}
+
+func (c *Client) createEmbeddings(ctx context.Context, baseReq EmbeddingRequest) (res EmbeddingResponse, err error) {
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/embeddings", string(baseReq.Model)),
+		withBody(baseReq), withRateLimitModel(baseReq.Model.String()))
 	if err != nil {
 		return
 	}
 
-	if c.config.EnableRateLimiter {
+	if c.rateLimiter != nil {
 		err = c.rateLimiter.WaitForRequest(ctx, baseReq.Model.String(), baseReq)
 		if err != nil {
 			return
@@ -277,11 +408,12 @@ func (c *Client) CreateEmbeddings(
 	return
 }
 
-// String implements the fmt.Stringer interface.
+// String implements the fmt.Stringer interface. The models missing from the enum, such as Azure
+// deployments, are returned as is.
 func (e EmbeddingModel) String() string {
 	res := enumToString[e]
 	if res == "" {
-		return enumToString[AdaEmbeddingV2]
+		return string(e)
 	}
 	return res
 }
@@ -304,4 +436,6 @@ var enumToString = map[EmbeddingModel]string{
 	BabbageCodeSearchCode: "code-search-babbage-code-001",
 	BabbageCodeSearchText: "code-search-babbage-text-001",
 	AdaEmbeddingV2:        "text-embedding-ada-002",
+	SmallEmbedding3:       "text-embedding-3-small",
+	LargeEmbedding3:       "text-embedding-3-large",
 }
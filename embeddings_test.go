@@ -33,6 +33,8 @@ func TestEmbedding(t *testing.T) {
 		openai.AdaCodeSearchText,
 		openai.BabbageCodeSearchCode,
 		openai.BabbageCodeSearchText,
+		openai.SmallEmbedding3,
+		openai.LargeEmbedding3,
 	}
 	for _, model := range embeddedModels {
 		// test embedding request with strings (simple embedding request)
@@ -303,7 +305,7 @@ func TestEmbeddingRequest_Tokens(t *testing.T) {
 	testcases := []struct {
 		name       string
 		model      openai.EmbeddingModel
-		input      []string
+		input      any
 		wantErr    error
 		wantTokens int
 	}{
@@ -319,6 +321,36 @@ func TestEmbeddingRequest_Tokens(t *testing.T) {
 			},
 			wantTokens: 7,
 		},
+		{
+			name:  "test strings",
+			model: openai.SmallEmbedding3,
+			input: []string{
+				"The food was delicious and the waiter",
+				"The food was delicious and the waiter",
+			},
+			wantTokens: 14,
+		},
+		{
+			name:       "test string",
+			model:      openai.LargeEmbedding3,
+			input:      "The food was delicious and the waiter",
+			wantTokens: 7,
+		},
+		{
+			name:       "test deployment name",
+			model:      "azure-embed-small",
+			input:      "The food was delicious and the waiter",
+			wantTokens: 7,
+		},
+		{
+			name:  "test tokens",
+			model: openai.SmallEmbedding3,
+			input: [][]int{
+				{464, 2057, 373, 12625, 290, 262, 46612},
+				{6395, 6096, 286, 11525, 12083, 2581},
+			},
+			wantTokens: 13,
+		},
 	}
 
 	for _, testcase := range testcases {
@@ -342,3 +374,93 @@ func TestEmbeddingRequest_Tokens(t *testing.T) {
 		})
 	}
 }
+
+func TestEmbeddingDimensions(t *testing.T) {
+	models := []openai.EmbeddingModel{openai.SmallEmbedding3, openai.LargeEmbedding3, "azure-embed-small"}
+	for _, model := range models {
+		if model.String() != string(model) {
+			t.Fatalf("String() = %s, want %s", model.String(), model)
+		}
+	}
+
+	requests := []openai.EmbeddingRequestConverter{
+		openai.EmbeddingRequestStrings{Input: []string{"Hello"}, Model: openai.SmallEmbedding3, Dimensions: 256},
+		openai.EmbeddingRequestTokens{Input: [][]int{{9906}}, Model: openai.SmallEmbedding3, Dimensions: 256},
+	}
+	for _, request := range requests {
+		marshaled, err := json.Marshal(request.Convert())
+		checks.NoError(t, err, "Could not marshal embedding request")
+		if !bytes.Contains(marshaled, []byte(`"dimensions":256`)) {
+			t.Fatalf("Expected embedding request to contain dimensions field: %s", marshaled)
+		}
+	}
+	marshaled, err := json.Marshal(openai.EmbeddingRequest{Model: openai.AdaEmbeddingV2})
+	checks.NoError(t, err, "Could not marshal embedding request")
+	if bytes.Contains(marshaled, []byte("dimensions")) {
+		t.Fatalf("Expected embedding request to omit dimensions field: %s", marshaled)
+	}
+}
+
+func TestEmbeddingTruncate(t *testing.T) {
+	response := openai.EmbeddingResponse{Data: []openai.Embedding{
+		{Embedding: []float32{3, 4, 12}},
+		{Embedding: []float32{0, 0, 1}},
+	}}
+	err := response.Truncate(2)
+	checks.NoError(t, err, "Truncate error")
+	if !reflect.DeepEqual(response.Data[0].Embedding, []float32{0.6, 0.8}) {
+		t.Errorf("Unexpected embedding %v", response.Data[0].Embedding)
+	}
+	if !reflect.DeepEqual(response.Data[1].Embedding, []float32{0, 0}) {
+		t.Errorf("Unexpected embedding %v", response.Data[1].Embedding)
+	}
+
+	err = response.Data[0].Truncate(3)
+	checks.ErrorIs(t, err, openai.ErrEmbeddingInvalidDimensions, "Truncate should fail for more dimensions")
+	err = response.Data[0].Truncate(0)
+	checks.ErrorIs(t, err, openai.ErrEmbeddingInvalidDimensions, "Truncate should fail for no dimensions")
+}
+
+func TestEmbeddingVectorMath(t *testing.T) {
+	v1 := &openai.Embedding{Embedding: []float32{3, 4}}
+	v2 := &openai.Embedding{Embedding: []float32{4, 3}}
+
+	similarity, err := v1.CosineSimilarity(v2)
+	checks.NoError(t, err, "CosineSimilarity error")
+	if math.Abs(float64(similarity)-0.96) > 1e-6 {
+		t.Errorf("Unexpected cosine similarity %v", similarity)
+	}
+	distance, err := v1.L2Distance(v2)
+	checks.NoError(t, err, "L2Distance error")
+	if math.Abs(float64(distance)-math.Sqrt2) > 1e-6 {
+		t.Errorf("Unexpected L2 distance %v", distance)
+	}
+
+	mean, err := openai.MeanPooling([]openai.Embedding{*v1, *v2})
+	checks.NoError(t, err, "MeanPooling error")
+	if !reflect.DeepEqual(mean.Embedding, []float32{3.5, 3.5}) {
+		t.Errorf("Unexpected mean %v", mean.Embedding)
+	}
+
+	v1.Normalize()
+	if !reflect.DeepEqual(v1.Embedding, []float32{0.6, 0.8}) {
+		t.Errorf("Unexpected normalized embedding %v", v1.Embedding)
+	}
+	zero := &openai.Embedding{Embedding: []float32{0, 0}}
+	zero.Normalize()
+	similarity, err = zero.CosineSimilarity(v1)
+	checks.NoError(t, err, "CosineSimilarity error")
+	if similarity != 0 || !reflect.DeepEqual(zero.Embedding, []float32{0, 0}) {
+		t.Errorf("Unexpected similarity %v of zero vector %v", similarity, zero.Embedding)
+	}
+
+	short := &openai.Embedding{Embedding: []float32{1}}
+	_, err = v1.CosineSimilarity(short)
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "CosineSimilarity should fail for different lengths")
+	_, err = v1.L2Distance(short)
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "L2Distance should fail for different lengths")
+	_, err = openai.MeanPooling([]openai.Embedding{*v1, *short})
+	checks.ErrorIs(t, err, openai.ErrVectorLengthMismatch, "MeanPooling should fail for different lengths")
+	_, err = openai.MeanPooling(nil)
+	checks.ErrorIs(t, err, openai.ErrNoEmbeddings, "MeanPooling should fail without embeddings")
+}
@@ -15,6 +15,8 @@ type APIError struct {
 	Type           string      `json:"type"`
 	HTTPStatusCode int         `json:"-"`
 	InnerError     *InnerError `json:"innererror,omitempty"`
+	// Metadata is the metadata of the response, e.g. the request ID.
+	Metadata ResponseMetadata `json:"-"`
 }
 
 // InnerError Azure Content filtering. Only valid for Azure OpenAI Service.
@@ -27,6 +29,8 @@ type InnerError struct {
 type RequestError struct {
 	HTTPStatusCode int
 	Err            error
+	// Metadata is the metadata of the response, e.g. the request ID.
+	Metadata ResponseMetadata
 }
 
 type ErrorResponse struct {
@@ -0,0 +1,139 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrorClass is a class of failures of the API. Errors returned by the client can be matched
+// against the classes with errors.Is, e.g. errors.Is(err, openai.ErrRateLimited).
+type ErrorClass struct {
+	name      string
+	retryable bool
+}
+
+func (c *ErrorClass) Error() string {
+	return c.name
+}
+
+// Retryable reports whether requests failing with errors of the class may succeed when retried.
+func (c *ErrorClass) Retryable() bool {
+	return c.retryable
+}
+
+var (
+	// ErrRateLimited is the class of requests exceeding the rate limits of the account.
+	ErrRateLimited = &ErrorClass{name: "rate limited", retryable: true}
+	// ErrQuotaExceeded is the class of requests exceeding the quota or the billing limits of the account.
+	ErrQuotaExceeded = &ErrorClass{name: "quota exceeded"}
+	// ErrContextLengthExceeded is the class of requests exceeding the context length of the model.
+	ErrContextLengthExceeded = &ErrorClass{name: "context length exceeded"}
+	// ErrInvalidAPIKey is the class of requests with a missing or invalid API key.
+	ErrInvalidAPIKey = &ErrorClass{name: "invalid API key"}
+	// ErrContentFiltered is the class of requests rejected by content filters, including the content
+	// filters of Azure OpenAI Service.
+	ErrContentFiltered = &ErrorClass{name: "content filtered"}
+	// ErrServerOverloaded is the class of requests failing because of the server, e.g. because it's overloaded.
+	ErrServerOverloaded = &ErrorClass{name: "server overloaded", retryable: true}
+	// ErrTimeout is the class of requests which timed out, on the server, in the client or in a stream.
+	ErrTimeout = &ErrorClass{name: "timeout", retryable: true}
+)
+
+const statusSiteOverloaded = 529
+
+// ClassifyError returns the class of an error returned by the client, or nil when the error
+// doesn't belong to any class. Unlike errors.Is, it also classifies the errors of expired
+// contexts and the timeouts of the network as ErrTimeout.
+func ClassifyError(err error) *ErrorClass {
+	var (
+		apiErr *APIError
+		reqErr *RequestError
+		netErr net.Error
+		class  *ErrorClass
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.class()
+	case errors.As(err, &reqErr):
+		if statusClass := classifyStatusCode(reqErr.HTTPStatusCode); statusClass != nil {
+			return statusClass
+		}
+	case errors.As(err, &class):
+		return class
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrStreamTimeout) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrTimeout
+	}
+	return nil
+}
+
+// IsRetryableError reports whether the class of the error is retryable.
+func IsRetryableError(err error) bool {
+	class := ClassifyError(err)
+	return class != nil && class.Retryable()
+}
+
+// Is matches the APIError with its ErrorClass.
+func (e *APIError) Is(target error) bool {
+	class, ok := target.(*ErrorClass)
+	return ok && class == e.class()
+}
+
+// Is matches the RequestError with the ErrorClass of its status code.
+func (e *RequestError) Is(target error) bool {
+	class, ok := target.(*ErrorClass)
+	return ok && class == classifyStatusCode(e.HTTPStatusCode)
+}
+
+//nolint:gocyclo
+func (e *APIError) class() *ErrorClass {
+	code, _ := e.Code.(string)
+	switch {
+	case code == "insufficient_quota" || e.Type == "insufficient_quota" ||
+		code == "billing_hard_limit_reached":
+		return ErrQuotaExceeded
+	case code == "context_length_exceeded" || strings.Contains(e.Message, "maximum context length"):
+		return ErrContextLengthExceeded
+	case code == "invalid_api_key":
+		return ErrInvalidAPIKey
+	case code == "content_filter" || code == "content_policy_violation" || e.contentFiltered():
+		return ErrContentFiltered
+	case code == "rate_limit_exceeded" || e.Type == "rate_limit_exceeded" ||
+		e.Type == "requests" || e.Type == "tokens":
+		return ErrRateLimited
+	case code == "timeout":
+		return ErrTimeout
+	case e.Type == "server_error" || strings.Contains(strings.ToLower(e.Message), "overloaded"):
+		return ErrServerOverloaded
+	}
+	return classifyStatusCode(e.HTTPStatusCode)
+}
+
+// contentFiltered reports whether the content filters of Azure OpenAI Service rejected the request.
+func (e *APIError) contentFiltered() bool {
+	if e.InnerError == nil {
+		return false
+	}
+	results := e.InnerError.ContentFilterResults
+	return e.InnerError.Code == "ResponsibleAIPolicyViolation" ||
+		results.Hate.Filtered || results.SelfHarm.Filtered || results.Sexual.Filtered || results.Violence.Filtered
+}
+
+func classifyStatusCode(statusCode int) *ErrorClass {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return ErrInvalidAPIKey
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return ErrTimeout
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, statusSiteOverloaded:
+		return ErrServerOverloaded
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package openai_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
@@ -277,3 +279,112 @@ func TestRequestError(t *testing.T) {
 		t.Fatalf("Empty request error occurred")
 	}
 }
+
+func TestErrorClasses(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		class     *openai.ErrorClass
+		retryable bool
+	}{
+		{
+			name:      "rate limit",
+			err:       &openai.APIError{Code: "rate_limit_exceeded", Type: "requests", HTTPStatusCode: 429},
+			class:     openai.ErrRateLimited,
+			retryable: true,
+		},
+		{
+			name:  "quota reported with status 429",
+			err:   &openai.APIError{Code: "insufficient_quota", Type: "insufficient_quota", HTTPStatusCode: 429},
+			class: openai.ErrQuotaExceeded,
+		},
+		{
+			name: "context length",
+			err: &openai.APIError{
+				Message:        "This model's maximum context length is 4097 tokens.",
+				Type:           "invalid_request_error",
+				HTTPStatusCode: 400,
+			},
+			class: openai.ErrContextLengthExceeded,
+		},
+		{
+			name:  "invalid API key",
+			err:   &openai.APIError{Code: "invalid_api_key", Type: "invalid_request_error", HTTPStatusCode: 401},
+			class: openai.ErrInvalidAPIKey,
+		},
+		{
+			name: "Azure content filter",
+			err: &openai.APIError{
+				Code:           "content_filter",
+				HTTPStatusCode: 400,
+				InnerError: &openai.InnerError{
+					Code:                 "ResponsibleAIPolicyViolation",
+					ContentFilterResults: openai.ContentFilterResults{Hate: openai.Hate{Filtered: true}},
+				},
+			},
+			class: openai.ErrContentFiltered,
+		},
+		{
+			name: "Azure content filter results",
+			err: &openai.APIError{InnerError: &openai.InnerError{
+				ContentFilterResults: openai.ContentFilterResults{Violence: openai.Violence{Filtered: true}},
+			}},
+			class: openai.ErrContentFiltered,
+		},
+		{
+			name:      "overloaded server",
+			err:       &openai.APIError{Message: "That model is currently overloaded", Type: "server_error"},
+			class:     openai.ErrServerOverloaded,
+			retryable: true,
+		},
+		{
+			name:      "wrapped request error",
+			err:       fmt.Errorf("error, %w", &openai.RequestError{HTTPStatusCode: 503, Err: errors.New("busy")}),
+			class:     openai.ErrServerOverloaded,
+			retryable: true,
+		},
+		{
+			name:      "gateway timeout",
+			err:       &openai.RequestError{HTTPStatusCode: 504, Err: errors.New("timeout")},
+			class:     openai.ErrTimeout,
+			retryable: true,
+		},
+		{
+			name:  "bad request",
+			err:   &openai.APIError{Type: "invalid_request_error", HTTPStatusCode: 400},
+			class: nil,
+		},
+	}
+	classes := []*openai.ErrorClass{
+		openai.ErrRateLimited, openai.ErrQuotaExceeded, openai.ErrContextLengthExceeded, openai.ErrInvalidAPIKey,
+		openai.ErrContentFiltered, openai.ErrServerOverloaded, openai.ErrTimeout,
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if class := openai.ClassifyError(tc.err); class != tc.class {
+				t.Fatalf("ClassifyError() = %v, want %v", class, tc.class)
+			}
+			for _, class := range classes {
+				if errors.Is(tc.err, class) != (class == tc.class) {
+					t.Errorf("errors.Is(err, %v) = %t", class, errors.Is(tc.err, class))
+				}
+			}
+			if openai.IsRetryableError(tc.err) != tc.retryable {
+				t.Errorf("IsRetryableError() = %t, want %t", !tc.retryable, tc.retryable)
+			}
+		})
+	}
+}
+
+func TestClassifyTimeouts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	if openai.ClassifyError(fmt.Errorf("request failed: %w", ctx.Err())) != openai.ErrTimeout {
+		t.Fatal("expired contexts aren't timeouts")
+	}
+	if openai.ClassifyError(context.Canceled) != nil {
+		t.Fatal("canceled contexts aren't timeouts")
+	}
+}
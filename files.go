@@ -23,6 +23,8 @@ const (
 	PurposeFineTuneResults  PurposeType = "fine-tune-results"
 	PurposeAssistants       PurposeType = "assistants"
 	PurposeAssistantsOutput PurposeType = "assistants_output"
+	PurposeBatch            PurposeType = "batch"
+	PurposeBatchOutput      PurposeType = "batch_output"
 )
 
 // FileBytesRequest represents a file upload request.
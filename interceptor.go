@@ -0,0 +1,60 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestHandler sends a request to the API.
+//
+// A nil error always comes with a successful response. When the API responds with
+// a failure status code, the response is returned together with the decoded
+// *APIError or *RequestError and its body has already been consumed and closed.
+type RequestHandler func(req *http.Request) (*http.Response, error)
+
+// Interceptor wraps every request sent by the Client, including the streaming and
+// raw endpoints. It may modify the request before calling next and inspect the
+// response or error afterwards. Every retry attempt passes through the interceptors.
+//
+// An interceptor which doesn't return the response of next must close its body.
+type Interceptor func(req *http.Request, next RequestHandler) (*http.Response, error)
+
+type requestPayloadKey struct{}
+
+// RequestPayload returns the value used to build the body of a request created by
+// the Client, such as a ChatCompletionRequest, or nil if the request has no body.
+func RequestPayload(req *http.Request) any {
+	return req.Context().Value(requestPayloadKey{})
+}
+
+func withRequestPayload(ctx context.Context, body any) context.Context {
+	if body == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, requestPayloadKey{}, body)
+}
+
+// send passes req through the configured interceptors and sends it with HTTPClient.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	handler := c.roundTrip
+	for i := len(c.config.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := c.config.Interceptors[i], handler
+		handler = func(req *http.Request) (*http.Response, error) {
+			return interceptor(req, next)
+		}
+	}
+	return handler(req)
+}
+
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if isFailureStatusCode(resp) {
+		defer resp.Body.Close()
+		return resp, c.handleErrorResp(resp)
+	}
+	return resp, nil
+}
@@ -0,0 +1,131 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func setupInterceptorTestServer(interceptors ...openai.Interceptor) (
+	client *openai.Client,
+	server *test.ServerTest,
+	teardown func(),
+) {
+	return setupOpenAITestServerWithConfig(func(config *openai.ClientConfig) {
+		config.Interceptors = interceptors
+	})
+}
+
+func TestInterceptorsOrderAndHeaders(t *testing.T) {
+	var calls []string
+	record := func(name string) openai.Interceptor {
+		return func(req *http.Request, next openai.RequestHandler) (*http.Response, error) {
+			calls = append(calls, name+":before")
+			req.Header.Set("X-"+name, "1")
+			resp, err := next(req)
+			calls = append(calls, name+":after")
+			return resp, err
+		}
+	}
+
+	client, server, teardown := setupInterceptorTestServer(record("First"), record("Second"))
+	defer teardown()
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-First") == "" || r.Header.Get("X-Second") == "" {
+			t.Error("headers set by interceptors are missing")
+		}
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+
+	_, err := client.ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+
+	expected := []string{"First:before", "Second:before", "Second:after", "First:after"}
+	if fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Fatalf("unexpected call order: %v, expected %v", calls, expected)
+	}
+}
+
+func TestInterceptorSeesAPIError(t *testing.T) {
+	var (
+		seenErr    error
+		seenStatus int
+	)
+	client, server, teardown := setupInterceptorTestServer(
+		func(req *http.Request, next openai.RequestHandler) (*http.Response, error) {
+			resp, err := next(req)
+			seenErr = err
+			if resp != nil {
+				seenStatus = resp.StatusCode
+			}
+			return resp, err
+		},
+	)
+	defer teardown()
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"bad request","type":"invalid_request_error"}}`)
+	})
+
+	_, err := client.ListModels(context.Background())
+	checks.HasError(t, err, "ListModels should fail")
+
+	apiErr := &openai.APIError{}
+	if !errors.As(seenErr, &apiErr) || apiErr.Type != "invalid_request_error" {
+		t.Fatalf("interceptor didn't see the decoded APIError: %v", seenErr)
+	}
+	if seenStatus != http.StatusBadRequest {
+		t.Fatalf("interceptor didn't see the response status: %d", seenStatus)
+	}
+}
+
+func TestInterceptorRequestPayload(t *testing.T) {
+	var payload any
+	client, server, teardown := setupInterceptorTestServer(
+		func(req *http.Request, next openai.RequestHandler) (*http.Response, error) {
+			payload = openai.RequestPayload(req)
+			return next(req)
+		},
+	)
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+
+	request, ok := payload.(openai.ChatCompletionRequest)
+	if !ok || request.Model != openai.GPT3Dot5Turbo || !request.Stream {
+		t.Fatalf("unexpected request payload: %#v", payload)
+	}
+}
+
+func TestInterceptorShortCircuitRaw(t *testing.T) {
+	client, _, teardown := setupInterceptorTestServer(
+		func(req *http.Request, _ openai.RequestHandler) (*http.Response, error) {
+			return nil, errors.New("blocked " + req.URL.Path)
+		},
+	)
+	defer teardown()
+
+	_, err := client.GetFileContent(context.Background(), "file-1")
+	checks.ErrorContains(t, err, "blocked /v1/files/file-1/content", "interceptor error wasn't returned")
+
+	_, err = client.CreateSpeech(context.Background(), openai.CreateSpeechRequest{
+		Model: openai.TTSModel1,
+		Voice: openai.VoiceAlloy,
+	})
+	checks.ErrorContains(t, err, "blocked /v1/audio/speech", "interceptor error wasn't returned")
+}
@@ -0,0 +1,141 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// RedisError is an error reply sent by a Redis server.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return string(e)
+}
+
+var errRedisProtocol = errors.New("redis: invalid reply")
+
+// RedisConn is a minimal client connection speaking the Redis serialization protocol (RESP2).
+type RedisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+func NewRedisConn(conn net.Conn) *RedisConn {
+	return &RedisConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+}
+
+// Do sends a command and reads its reply. Replies are returned as string, int64,
+// []any or nil; error replies are returned as RedisError.
+func (c *RedisConn) Do(ctx context.Context, args ...string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Time{}
+	}
+	err := c.conn.SetDeadline(deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.writeCommand(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.readReply()
+}
+
+func (c *RedisConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *RedisConn) writeCommand(args []string) error {
+	fmt.Fprintf(c.writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return c.writer.Flush()
+}
+
+func (c *RedisConn) readReply() (any, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, errRedisProtocol
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		return c.readBulk(line[1:])
+	case '*':
+		return c.readArray(line[1:])
+	default:
+		return nil, errRedisProtocol
+	}
+}
+
+func (c *RedisConn) readBulk(size string) (any, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, errRedisProtocol
+	}
+	if n < 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, n+2)
+	_, err = io.ReadFull(c.reader, buf)
+	if err != nil {
+		return nil, err
+	}
+	return string(buf[:n]), nil
+}
+
+func (c *RedisConn) readArray(size string) (any, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, errRedisProtocol
+	}
+	if n < 0 {
+		return nil, nil
+	}
+
+	items := make([]any, n)
+	for i := range items {
+		items[i], err = c.readReply()
+		if err != nil && !errors.As(err, new(RedisError)) {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (c *RedisConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errRedisProtocol
+	}
+	return line[:len(line)-2], nil
+}
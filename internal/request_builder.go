@@ -5,6 +5,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"strings"
 )
 
 type RequestBuilder interface {
@@ -31,7 +32,10 @@ func (b *HTTPRequestBuilder) Build(
 	var bodyReader io.Reader
 	if body != nil {
 		if v, ok := body.(io.Reader); ok {
-			bodyReader = v
+			bodyReader, err = replayableReader(v)
+			if err != nil {
+				return
+			}
 		} else {
 			var reqBytes []byte
 			reqBytes, err = b.marshaller.Marshal(body)
@@ -50,3 +54,18 @@ func (b *HTTPRequestBuilder) Build(
 	}
 	return
 }
+
+// replayableReader makes sure http.NewRequest can set GetBody for the reader,
+// so that the request body can be sent again when the request is retried.
+func replayableReader(r io.Reader) (io.Reader, error) {
+	switch r.(type) {
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		return r, nil
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
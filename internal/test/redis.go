@@ -0,0 +1,168 @@
+package test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RedisError is an error reply sent by the RedisTestServer.
+type RedisError string
+
+// RedisCommandHandler handles a command and returns its reply, which can be
+// a string, an int, an int64, a []any, a RedisError or nil.
+type RedisCommandHandler func(args []string) any
+
+// RedisTestServer is a fake server speaking the Redis protocol. Commands are
+// dispatched to the registered handlers; PING, AUTH and SELECT are handled by default.
+type RedisTestServer struct {
+	listener net.Listener
+
+	mutex    sync.Mutex
+	handlers map[string]RedisCommandHandler
+	commands [][]string
+}
+
+// NewRedisTestServer starts a fake Redis server on a random local port.
+func NewRedisTestServer() (*RedisTestServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	ok := func([]string) any { return "OK" }
+	ts := &RedisTestServer{
+		listener: listener,
+		handlers: map[string]RedisCommandHandler{
+			"PING":   func([]string) any { return "PONG" },
+			"AUTH":   ok,
+			"SELECT": ok,
+		},
+	}
+	go ts.serve()
+	return ts, nil
+}
+
+// Addr returns the address the server listens on.
+func (ts *RedisTestServer) Addr() string {
+	return ts.listener.Addr().String()
+}
+
+// RegisterHandler registers the handler of a command.
+func (ts *RedisTestServer) RegisterHandler(command string, handler RedisCommandHandler) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.handlers[strings.ToUpper(command)] = handler
+}
+
+// Commands returns all the commands received so far.
+func (ts *RedisTestServer) Commands() [][]string {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	return append([][]string(nil), ts.commands...)
+}
+
+// Close stops the server.
+func (ts *RedisTestServer) Close() error {
+	return ts.listener.Close()
+}
+
+func (ts *RedisTestServer) serve() {
+	for {
+		conn, err := ts.listener.Accept()
+		if err != nil {
+			return
+		}
+		go ts.handle(conn)
+	}
+}
+
+func (ts *RedisTestServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	for {
+		args, err := readRedisCommand(reader)
+		if err != nil {
+			return
+		}
+
+		ts.mutex.Lock()
+		ts.commands = append(ts.commands, args)
+		handler, ok := ts.handlers[strings.ToUpper(args[0])]
+		ts.mutex.Unlock()
+
+		var reply any = RedisError("ERR unknown command '" + args[0] + "'")
+		if ok {
+			reply = handler(args[1:])
+		}
+
+		writeRedisReply(writer, reply)
+		if writer.Flush() != nil {
+			return
+		}
+	}
+}
+
+func readRedisCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected command: %q", line)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("unexpected command: %q", line)
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		var size int
+		size, err = strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func writeRedisReply(writer *bufio.Writer, reply any) {
+	switch v := reply.(type) {
+	case nil:
+		writer.WriteString("$-1\r\n")
+	case RedisError:
+		fmt.Fprintf(writer, "-%s\r\n", v)
+	case int:
+		fmt.Fprintf(writer, ":%d\r\n", v)
+	case int64:
+		fmt.Fprintf(writer, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(v), v)
+	case []any:
+		fmt.Fprintf(writer, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeRedisReply(writer, item)
+		}
+	default:
+		fmt.Fprintf(writer, "-ERR unsupported reply %T\r\n", v)
+	}
+}
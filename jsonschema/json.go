@@ -4,7 +4,10 @@
 // and/or pass in the schema in []byte format.
 package jsonschema
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type DataType string
 
@@ -23,27 +26,176 @@ const (
 type Definition struct {
 	// Type specifies the data type of the schema.
 	Type DataType `json:"type,omitempty"`
+	// Nullable allows null values in addition to Type. The type is encoded as a type array, e.g. ["string", "null"].
+	Nullable bool `json:"-"`
 	// Description is the description of the schema.
 	Description string `json:"description,omitempty"`
 	// Enum is used to restrict a value to a fixed set of values. It must be an array with at least
 	// one element, where each element is unique. You will probably only use this with strings.
 	Enum []string `json:"enum,omitempty"`
+	// EnumValues are the values of the enum which aren't strings, e.g. numbers, booleans or null.
+	// They are encoded after the values of Enum. Decoded enums with any non-string value are stored here.
+	EnumValues []any `json:"-"`
+	// Const restricts a value to a single value.
+	Const any `json:"const,omitempty"`
+	// Default is the default value of the schema.
+	Default any `json:"default,omitempty"`
+	// Format is the semantic format of a string, e.g. "date-time", "email" or "uuid".
+	Format string `json:"format,omitempty"`
+	// Pattern is a regular expression which strings must match.
+	Pattern string `json:"pattern,omitempty"`
+	// MinLength and MaxLength bound the length of strings.
+	MinLength *int `json:"minLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty"`
+	// Minimum, Maximum, ExclusiveMinimum and ExclusiveMaximum bound numbers.
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	// MultipleOf restricts numbers to multiples of a number.
+	MultipleOf *float64 `json:"multipleOf,omitempty"`
 	// Properties describes the properties of an object, if the schema type is Object.
 	Properties map[string]Definition `json:"properties"`
 	// Required specifies which properties are required, if the schema type is Object.
 	Required []string `json:"required,omitempty"`
+	// AdditionalProperties is false, true, or the Definition of the properties of an object
+	// which aren't listed in Properties.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
 	// Items specifies which data type an array contains, if the schema type is Array.
 	Items *Definition `json:"items,omitempty"`
+	// MinItems and MaxItems bound the length of arrays.
+	MinItems *int `json:"minItems,omitempty"`
+	MaxItems *int `json:"maxItems,omitempty"`
+	// UniqueItems requires the items of arrays to be unique.
+	UniqueItems bool `json:"uniqueItems,omitempty"`
+	// AnyOf, OneOf and AllOf combine schemas: a value must match any, exactly one or all of them.
+	AnyOf []Definition `json:"anyOf,omitempty"`
+	OneOf []Definition `json:"oneOf,omitempty"`
+	AllOf []Definition `json:"allOf,omitempty"`
+	// Ref references another schema, e.g. "#/$defs/address".
+	Ref string `json:"$ref,omitempty"`
+	// Defs are schemas which can be referenced by Ref.
+	Defs map[string]Definition `json:"$defs,omitempty"`
 }
 
 func (d Definition) MarshalJSON() ([]byte, error) {
 	if d.Properties == nil {
 		d.Properties = make(map[string]Definition)
 	}
+
+	var typ any
+	if d.Type != "" {
+		typ = d.Type
+		if d.Nullable && d.Type != Null {
+			typ = []DataType{d.Type, Null}
+		}
+	}
+
+	var enum []any
+	for _, value := range d.Enum {
+		enum = append(enum, value)
+	}
+	enum = append(enum, d.EnumValues...)
+
+	switch d.AdditionalProperties.(type) {
+	case nil, bool, Definition, *Definition:
+	default:
+		return nil, fmt.Errorf("jsonschema: unsupported additionalProperties %T", d.AdditionalProperties)
+	}
+
 	type Alias Definition
 	return json.Marshal(struct {
+		Type any   `json:"type,omitempty"`
+		Enum []any `json:"enum,omitempty"`
 		Alias
 	}{
+		Type:  typ,
+		Enum:  enum,
 		Alias: (Alias)(d),
 	})
 }
+
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	type Alias Definition
+	aux := struct {
+		Type                 json.RawMessage `json:"type,omitempty"`
+		Enum                 []any           `json:"enum,omitempty"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(d),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if err := d.unmarshalType(aux.Type); err != nil {
+		return err
+	}
+	d.unmarshalEnum(aux.Enum)
+	return d.unmarshalAdditionalProperties(aux.AdditionalProperties)
+}
+
+func (d *Definition) unmarshalType(data json.RawMessage) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	var types []DataType
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &types); err != nil {
+			return err
+		}
+	} else {
+		if err := json.Unmarshal(data, &d.Type); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	for _, t := range types {
+		switch {
+		case t == Null && len(types) > 1:
+			d.Nullable = true
+		case d.Type == "":
+			d.Type = t
+		default:
+			return fmt.Errorf("jsonschema: unsupported type %s, only a single type and null are supported", data)
+		}
+	}
+	return nil
+}
+
+func (d *Definition) unmarshalEnum(values []any) {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			d.EnumValues = values
+			return
+		}
+		strs = append(strs, s)
+	}
+	if len(strs) > 0 {
+		d.Enum = strs
+	}
+}
+
+func (d *Definition) unmarshalAdditionalProperties(data json.RawMessage) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		d.AdditionalProperties = allowed
+		return nil
+	}
+
+	var definition Definition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return err
+	}
+	d.AdditionalProperties = definition
+	return nil
+}
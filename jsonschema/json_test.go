@@ -209,3 +209,220 @@ func structToMap(t *testing.T, v any) map[string]any {
 	}
 	return got
 }
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestDefinition_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		def  jsonschema.Definition
+		want string
+	}{
+		{
+			name: "Test with combined schemas",
+			def: jsonschema.Definition{
+				AnyOf: []jsonschema.Definition{{Type: jsonschema.String}, {Type: jsonschema.Integer}},
+				OneOf: []jsonschema.Definition{{Type: jsonschema.Number, Minimum: float64Ptr(0)}},
+				AllOf: []jsonschema.Definition{{Type: jsonschema.Number, Maximum: float64Ptr(10)}},
+			},
+			want: `{
+   "anyOf":[{"type":"string","properties":{}},{"type":"integer","properties":{}}],
+   "oneOf":[{"type":"number","minimum":0,"properties":{}}],
+   "allOf":[{"type":"number","maximum":10,"properties":{}}],
+   "properties":{}
+}`,
+		},
+		{
+			name: "Test with references",
+			def: jsonschema.Definition{
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"home": {Ref: "#/$defs/address"},
+				},
+				Defs: map[string]jsonschema.Definition{
+					"address": {
+						Type:                 jsonschema.Object,
+						Properties:           map[string]jsonschema.Definition{"city": {Type: jsonschema.String}},
+						AdditionalProperties: false,
+					},
+				},
+			},
+			want: `{
+   "type":"object",
+   "properties":{"home":{"$ref":"#/$defs/address","properties":{}}},
+   "$defs":{
+      "address":{
+         "type":"object",
+         "properties":{"city":{"type":"string","properties":{}}},
+         "additionalProperties":false
+      }
+   }
+}`,
+		},
+		{
+			name: "Test with additionalProperties schema",
+			def: jsonschema.Definition{
+				Type:                 jsonschema.Object,
+				AdditionalProperties: jsonschema.Definition{Type: jsonschema.Integer, Minimum: float64Ptr(1)},
+			},
+			want: `{
+   "type":"object",
+   "properties":{},
+   "additionalProperties":{"type":"integer","minimum":1,"properties":{}}
+}`,
+		},
+		{
+			name: "Test with bounds",
+			def: jsonschema.Definition{
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"name": {
+						Type:      jsonschema.String,
+						MinLength: intPtr(1),
+						MaxLength: intPtr(64),
+						Pattern:   "^[a-z]+$",
+						Format:    "hostname",
+						Default:   "localhost",
+					},
+					"ratio": {
+						Type:             jsonschema.Number,
+						ExclusiveMinimum: float64Ptr(0),
+						ExclusiveMaximum: float64Ptr(1),
+						MultipleOf:       float64Ptr(0.25),
+					},
+					"tags": {
+						Type:        jsonschema.Array,
+						Items:       &jsonschema.Definition{Type: jsonschema.String},
+						MinItems:    intPtr(0),
+						MaxItems:    intPtr(3),
+						UniqueItems: true,
+					},
+				},
+			},
+			want: `{
+   "type":"object",
+   "properties":{
+      "name":{
+         "type":"string",
+         "minLength":1,
+         "maxLength":64,
+         "pattern":"^[a-z]+$",
+         "format":"hostname",
+         "default":"localhost",
+         "properties":{}
+      },
+      "ratio":{
+         "type":"number",
+         "exclusiveMinimum":0,
+         "exclusiveMaximum":1,
+         "multipleOf":0.25,
+         "properties":{}
+      },
+      "tags":{
+         "type":"array",
+         "items":{"type":"string","properties":{}},
+         "minItems":0,
+         "maxItems":3,
+         "uniqueItems":true,
+         "properties":{}
+      }
+   }
+}`,
+		},
+		{
+			name: "Test with const and non-string enums",
+			def: jsonschema.Definition{
+				Type: jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{
+					"version": {Const: 2.0},
+					"level":   {Type: jsonschema.Integer, EnumValues: []any{1.0, 2.0, 3.0}},
+					"mixed":   {EnumValues: []any{"auto", true, nil}},
+					"unit":    {Type: jsonschema.String, Enum: []string{"celsius", "fahrenheit"}},
+				},
+			},
+			want: `{
+   "type":"object",
+   "properties":{
+      "version":{"const":2,"properties":{}},
+      "level":{"type":"integer","enum":[1,2,3],"properties":{}},
+      "mixed":{"enum":["auto",true,null],"properties":{}},
+      "unit":{"type":"string","enum":["celsius","fahrenheit"],"properties":{}}
+   }
+}`,
+		},
+		{
+			name: "Test with nullable type",
+			def: jsonschema.Definition{
+				Type:     jsonschema.String,
+				Nullable: true,
+			},
+			want: `{"type":["string","null"],"properties":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want map[string]any
+			err := json.Unmarshal([]byte(tt.want), &want)
+			if err != nil {
+				t.Fatalf("Failed to Unmarshal JSON: error = %v", err)
+			}
+
+			got := structToMap(t, tt.def)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("MarshalJSON() got = %v, want %v", got, want)
+			}
+
+			var decoded jsonschema.Definition
+			err = json.Unmarshal([]byte(tt.want), &decoded)
+			if err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(structToMap(t, decoded), want) {
+				t.Fatalf("UnmarshalJSON() doesn't round trip: %+v", decoded)
+			}
+		})
+	}
+}
+
+func TestDefinition_UnmarshalJSON(t *testing.T) {
+	var def jsonschema.Definition
+	err := json.Unmarshal([]byte(`{
+		"type":["null","integer"],
+		"enum":[1,null],
+		"additionalProperties":true,
+		"properties":{"name":{"type":"string","enum":["a","b"]}}
+	}`), &def)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+
+	want := jsonschema.Definition{
+		Type:                 jsonschema.Integer,
+		Nullable:             true,
+		EnumValues:           []any{1.0, nil},
+		AdditionalProperties: true,
+		Properties: map[string]jsonschema.Definition{
+			"name": {Type: jsonschema.String, Enum: []string{"a", "b"}},
+		},
+	}
+	if !reflect.DeepEqual(def, want) {
+		t.Fatalf("UnmarshalJSON() = %+v, want %+v", def, want)
+	}
+
+	err = json.Unmarshal([]byte(`{"type":["string","integer"]}`), &def)
+	if err == nil {
+		t.Fatalf("UnmarshalJSON() should fail for several non-null types")
+	}
+
+	_, err = json.Marshal(jsonschema.Definition{AdditionalProperties: "yes"})
+	if err == nil {
+		t.Fatalf("MarshalJSON() should fail for invalid additionalProperties")
+	}
+}
@@ -0,0 +1,213 @@
+package jsonschema
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// GenerateSchemaForType returns the Definition of the JSON encoding of the type of v.
+//
+// Struct fields are named by their json tags and are required unless they are tagged with omitempty,
+// pointer fields without omitempty are nullable. The description tag sets the description of a field,
+// and the enum tag restricts a field to a comma separated list of values, e.g.:
+//
+//	type Arguments struct {
+//		Location string `json:"location" description:"The city and state, e.g. San Francisco, CA"`
+//		Unit     string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+//	}
+func GenerateSchemaForType(v any) (*Definition, error) {
+	definition, err := reflectSchema(reflect.TypeOf(v), map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func reflectSchema(t reflect.Type, visiting map[reflect.Type]bool) (Definition, error) {
+	if t == nil {
+		return Definition{}, nil
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return Definition{Type: String, Format: "date-time"}, nil
+	}
+	// types encoded as text
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return Definition{Type: String}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Definition{Type: String}, nil
+	case reflect.Bool:
+		return Definition{Type: Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Definition{Type: Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return Definition{Type: Number}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as a base64 string
+			return Definition{Type: String}, nil
+		}
+		items, err := reflectSchema(t.Elem(), visiting)
+		if err != nil {
+			return Definition{}, err
+		}
+		return Definition{Type: Array, Items: &items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String && !t.Key().Implements(textMarshalerType) {
+			switch t.Key().Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			default:
+				return Definition{}, fmt.Errorf("jsonschema: unsupported map key type %s", t.Key())
+			}
+		}
+		values, err := reflectSchema(t.Elem(), visiting)
+		if err != nil {
+			return Definition{}, err
+		}
+		return Definition{Type: Object, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return Definition{}, fmt.Errorf("jsonschema: recursive type %s", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		definition := Definition{Type: Object, Properties: map[string]Definition{}}
+		err := reflectStructFields(t, &definition, false, visiting)
+		if err != nil {
+			return Definition{}, err
+		}
+		return definition, nil
+	case reflect.Interface:
+		// any value
+		return Definition{}, nil
+	default:
+		return Definition{}, fmt.Errorf("jsonschema: unsupported type %s", t)
+	}
+}
+
+func reflectStructFields(t reflect.Type, definition *Definition, promoted bool, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		// the fields of embedded structs are promoted, like encoding/json does
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if err := reflectStructFields(fieldType, definition, true, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, exists := definition.Properties[name]; exists {
+			if promoted {
+				// promoted fields are hidden by the fields of the outer struct
+				continue
+			}
+			definition.Required = remove(definition.Required, name)
+		}
+
+		property, err := reflectSchema(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("%w (field %s of %s)", err, field.Name, t)
+		}
+
+		if description := field.Tag.Get("description"); description != "" {
+			property.Description = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			err = setEnum(&property, strings.Split(enum, ","))
+			if err != nil {
+				return fmt.Errorf("%w (field %s of %s)", err, field.Name, t)
+			}
+		}
+
+		omitempty := contains(strings.Split(options, ","), "omitempty")
+		// nil pointers are encoded as null unless they are omitted
+		property.Nullable = field.Type.Kind() == reflect.Pointer && !omitempty
+
+		definition.Properties[name] = property
+		if !omitempty {
+			definition.Required = append(definition.Required, name)
+		}
+	}
+	return nil
+}
+
+// setEnum sets the enum of the definition, parsing the values according to its type.
+func setEnum(definition *Definition, values []string) error {
+	switch definition.Type {
+	case Integer, Number:
+		for _, value := range values {
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("jsonschema: invalid enum value %q of type %s", value, definition.Type)
+			}
+			definition.EnumValues = append(definition.EnumValues, number)
+		}
+	case Boolean:
+		for _, value := range values {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("jsonschema: invalid enum value %q of type %s", value, definition.Type)
+			}
+			definition.EnumValues = append(definition.EnumValues, b)
+		}
+	default:
+		definition.Enum = values
+	}
+	return nil
+}
+
+func remove(values []string, value string) []string {
+	kept := values[:0]
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type reflectAddress struct {
+	City    string `json:"city" description:"The name of the city"`
+	Country string `json:"country,omitempty"`
+}
+
+type reflectBase struct {
+	ID      int    `json:"id"`
+	Comment string `json:"comment,omitempty"`
+}
+
+type reflectArguments struct {
+	reflectBase
+	Name      string            `json:"name" description:"The name of the user"`
+	Unit      string            `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+	Age       *int              `json:"age,omitempty"`
+	Score     float64           `json:"score"`
+	Active    bool              `json:"active"`
+	Tags      []string          `json:"tags"`
+	Address   reflectAddress    `json:"address"`
+	Previous  []*reflectAddress `json:"previous,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	Parent    *reflectAddress   `json:"parent"`
+	Level     int               `json:"level" enum:"1,2,3"`
+	Extra     any               `json:"extra,omitempty"`
+	Data      []byte            `json:"data,omitempty"`
+	Untagged  string
+	Ignored   string `json:"-"`
+	internal  string
+}
+
+func TestGenerateSchemaForType(t *testing.T) {
+	address := jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"city":    {Type: jsonschema.String, Description: "The name of the city"},
+			"country": {Type: jsonschema.String},
+		},
+		Required: []string{"city"},
+	}
+	nullableAddress := address
+	nullableAddress.Nullable = true
+	want := &jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"id":         {Type: jsonschema.Integer},
+			"comment":    {Type: jsonschema.String},
+			"name":       {Type: jsonschema.String, Description: "The name of the user"},
+			"unit":       {Type: jsonschema.String, Enum: []string{"celsius", "fahrenheit"}},
+			"age":        {Type: jsonschema.Integer},
+			"score":      {Type: jsonschema.Number},
+			"active":     {Type: jsonschema.Boolean},
+			"tags":       {Type: jsonschema.Array, Items: &jsonschema.Definition{Type: jsonschema.String}},
+			"address":    address,
+			"previous":   {Type: jsonschema.Array, Items: &address},
+			"labels":     {Type: jsonschema.Object, AdditionalProperties: jsonschema.Definition{Type: jsonschema.String}},
+			"created_at": {Type: jsonschema.String, Format: "date-time"},
+			"parent":     nullableAddress,
+			"level":      {Type: jsonschema.Integer, EnumValues: []any{1.0, 2.0, 3.0}},
+			"extra":      {},
+			"data":       {Type: jsonschema.String},
+			"Untagged":   {Type: jsonschema.String},
+		},
+		Required: []string{"id", "name", "score", "active", "tags", "address", "created_at", "parent", "level", "Untagged"},
+	}
+
+	got, err := jsonschema.GenerateSchemaForType(reflectArguments{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Fatalf("GenerateSchemaForType() = %s, want %s", gotJSON, wantJSON)
+	}
+
+	pointer, err := jsonschema.GenerateSchemaForType(&reflectArguments{})
+	if err != nil || !reflect.DeepEqual(pointer, want) {
+		t.Fatalf("GenerateSchemaForType() of a pointer = %v, %v", pointer, err)
+	}
+}
+
+type reflectNode struct {
+	Children []reflectNode `json:"children"`
+}
+
+type reflectShadowed struct {
+	ID string `json:"id,omitempty"`
+	reflectBase
+}
+
+func TestGenerateSchemaForTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"recursive type", reflectNode{}},
+		{"channel", struct {
+			C chan int `json:"c"`
+		}{}},
+		{"map key", map[bool]string{}},
+		{"enum", struct {
+			Level int `json:"level" enum:"low,high"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jsonschema.GenerateSchemaForType(tt.v)
+			if err == nil {
+				t.Fatalf("GenerateSchemaForType() should fail")
+			}
+		})
+	}
+}
+
+func TestGenerateSchemaForTypeShadowedFields(t *testing.T) {
+	got, err := jsonschema.GenerateSchemaForType(reflectShadowed{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType() error = %v", err)
+	}
+
+	if got.Properties["id"].Type != jsonschema.String {
+		t.Fatalf("fields of the outer struct should hide promoted fields: %+v", got.Properties["id"])
+	}
+	if !reflect.DeepEqual(got.Required, []string(nil)) {
+		t.Fatalf("hidden promoted fields shouldn't be required: %v", got.Required)
+	}
+}
@@ -0,0 +1,444 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError is a violation of a schema by the value at Path. Paths start with $ for the
+// document, followed by .name for properties and [i] for items, e.g. $.users[0].name.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors are all the violations of a schema by a document.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateJSON validates a JSON document against the schema. It returns ValidationErrors when
+// the document doesn't match the schema.
+func ValidateJSON(schema Definition, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("jsonschema: invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("jsonschema: invalid JSON: unexpected data after the document")
+	}
+	return Validate(schema, value)
+}
+
+// Validate validates a value decoded from JSON, i.e. a map[string]any, []any, string, float64,
+// json.Number, bool or nil, against the schema. It returns ValidationErrors when the value
+// doesn't match the schema.
+func Validate(schema Definition, value any) error {
+	v := &validator{root: schema}
+	v.validate(schema, value, "$")
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+type validator struct {
+	root Definition
+	errs ValidationErrors
+	// depth of $ref resolution, limits recursive references
+	refDepth int
+}
+
+const maxRefDepth = 64
+
+func (v *validator) errorf(path, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+//nolint:gocognit,gocyclo
+func (v *validator) validate(schema Definition, value any, path string) {
+	if schema.Nullable && value == nil {
+		// null is valid whatever the other keywords, e.g. the enum of an optional field
+		return
+	}
+	if schema.Ref != "" {
+		v.validateRef(schema.Ref, value, path)
+	}
+
+	if schema.Type != "" && !hasType(value, schema.Type) {
+		v.errorf(path, "expected %s, got %s", typeName(schema), valueType(value))
+		// the other keywords are meaningless for a value of the wrong type
+		return
+	}
+
+	if len(schema.Enum) > 0 || len(schema.EnumValues) > 0 {
+		v.validateEnum(schema, value, path)
+	}
+	if schema.Const != nil && !equal(schema.Const, value) {
+		v.errorf(path, "must be %s", formatValue(schema.Const))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		v.validateObject(schema, value, path)
+	case []any:
+		v.validateArray(schema, value, path)
+	case string:
+		v.validateString(schema, value, path)
+	case json.Number, float64:
+		v.validateNumber(schema, toFloat(value), path)
+	}
+
+	v.validateCombinations(schema, value, path)
+}
+
+func (v *validator) validateRef(ref string, value any, path string) {
+	const prefix = "#/$defs/"
+	if ref != "#" && !strings.HasPrefix(ref, prefix) {
+		v.errorf(path, "unsupported reference %s", ref)
+		return
+	}
+
+	definition := v.root
+	if name := strings.TrimPrefix(ref, prefix); ref != "#" {
+		var ok bool
+		definition, ok = v.root.Defs[name]
+		if !ok {
+			v.errorf(path, "unknown reference %s", ref)
+			return
+		}
+	}
+
+	if v.refDepth >= maxRefDepth {
+		v.errorf(path, "too deeply nested references")
+		return
+	}
+	v.refDepth++
+	v.validate(definition, value, path)
+	v.refDepth--
+}
+
+func (v *validator) validateEnum(schema Definition, value any, path string) {
+	for _, allowed := range schema.Enum {
+		if equal(allowed, value) {
+			return
+		}
+	}
+	for _, allowed := range schema.EnumValues {
+		if equal(allowed, value) {
+			return
+		}
+	}
+
+	values := make([]string, 0, len(schema.Enum)+len(schema.EnumValues))
+	for _, allowed := range schema.Enum {
+		values = append(values, formatValue(allowed))
+	}
+	for _, allowed := range schema.EnumValues {
+		values = append(values, formatValue(allowed))
+	}
+	v.errorf(path, "must be one of %s", strings.Join(values, ", "))
+}
+
+func (v *validator) validateObject(schema Definition, object map[string]any, path string) {
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			v.errorf(propertyPath(path, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if property, ok := schema.Properties[name]; ok {
+			v.validate(property, object[name], propertyPath(path, name))
+			continue
+		}
+
+		switch additional := schema.AdditionalProperties.(type) {
+		case bool:
+			if !additional {
+				v.errorf(propertyPath(path, name), "is not allowed")
+			}
+		case Definition:
+			v.validate(additional, object[name], propertyPath(path, name))
+		case *Definition:
+			if additional != nil {
+				v.validate(*additional, object[name], propertyPath(path, name))
+			}
+		}
+	}
+}
+
+func (v *validator) validateArray(schema Definition, array []any, path string) {
+	if schema.MinItems != nil && len(array) < *schema.MinItems {
+		v.errorf(path, "must have at least %d items, got %d", *schema.MinItems, len(array))
+	}
+	if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+		v.errorf(path, "must have at most %d items, got %d", *schema.MaxItems, len(array))
+	}
+
+	if schema.UniqueItems {
+	unique:
+		for i := range array {
+			for j := 0; j < i; j++ {
+				if equal(array[i], array[j]) {
+					v.errorf(path, "items %d and %d must be unique", j, i)
+					break unique
+				}
+			}
+		}
+	}
+
+	if schema.Items != nil {
+		for i, item := range array {
+			v.validate(*schema.Items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (v *validator) validateString(schema Definition, s string, path string) {
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.errorf(path, "must be at least %d characters long, got %d", *schema.MinLength, length)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.errorf(path, "must be at most %d characters long, got %d", *schema.MaxLength, length)
+	}
+
+	if schema.Pattern != "" {
+		pattern, err := regexp.Compile(schema.Pattern)
+		switch {
+		case err != nil:
+			v.errorf(path, "invalid pattern %s: %v", schema.Pattern, err)
+		case !pattern.MatchString(s):
+			v.errorf(path, "must match pattern %s", schema.Pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(schema Definition, number float64, path string) {
+	if schema.Minimum != nil && number < *schema.Minimum {
+		v.errorf(path, "must be greater than or equal to %v", *schema.Minimum)
+	}
+	if schema.Maximum != nil && number > *schema.Maximum {
+		v.errorf(path, "must be less than or equal to %v", *schema.Maximum)
+	}
+	if schema.ExclusiveMinimum != nil && number <= *schema.ExclusiveMinimum {
+		v.errorf(path, "must be greater than %v", *schema.ExclusiveMinimum)
+	}
+	if schema.ExclusiveMaximum != nil && number >= *schema.ExclusiveMaximum {
+		v.errorf(path, "must be less than %v", *schema.ExclusiveMaximum)
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		quotient := number / *schema.MultipleOf
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.errorf(path, "must be a multiple of %v", *schema.MultipleOf)
+		}
+	}
+}
+
+func (v *validator) validateCombinations(schema Definition, value any, path string) {
+	for _, definition := range schema.AllOf {
+		v.validate(definition, value, path)
+	}
+
+	if len(schema.AnyOf) > 0 {
+		var nested ValidationErrors
+		for _, definition := range schema.AnyOf {
+			errs := v.nested(definition, value, path)
+			if len(errs) == 0 {
+				nested = nil
+				break
+			}
+			nested = append(nested, errs...)
+		}
+		if nested != nil {
+			v.errorf(path, "must match any of the schemas (%s)", nested.Error())
+		}
+	}
+
+	if len(schema.OneOf) > 0 {
+		matches := 0
+		var nested ValidationErrors
+		for _, definition := range schema.OneOf {
+			errs := v.nested(definition, value, path)
+			if len(errs) == 0 {
+				matches++
+			}
+			nested = append(nested, errs...)
+		}
+		switch {
+		case matches == 0:
+			v.errorf(path, "must match exactly one of the schemas (%s)", nested.Error())
+		case matches > 1:
+			v.errorf(path, "must match exactly one of the schemas, matches %d", matches)
+		}
+	}
+}
+
+// nested validates the value against a schema without adding the errors to the validator.
+func (v *validator) nested(schema Definition, value any, path string) ValidationErrors {
+	nested := &validator{root: v.root, refDepth: v.refDepth}
+	nested.validate(schema, value, path)
+	return nested.errs
+}
+
+func hasType(value any, t DataType) bool {
+	switch t {
+	case Object:
+		_, ok := value.(map[string]any)
+		return ok
+	case Array:
+		_, ok := value.([]any)
+		return ok
+	case String:
+		_, ok := value.(string)
+		return ok
+	case Boolean:
+		_, ok := value.(bool)
+		return ok
+	case Null:
+		return value == nil
+	case Number:
+		switch value.(type) {
+		case float64, json.Number:
+			return true
+		}
+		return false
+	case Integer:
+		switch value.(type) {
+		case float64, json.Number:
+			f := toFloat(value)
+			return f == math.Trunc(f) && !math.IsInf(f, 0)
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func typeName(schema Definition) string {
+	if schema.Nullable {
+		return string(schema.Type) + " or null"
+	}
+	return string(schema.Type)
+}
+
+func valueType(value any) string {
+	switch value := value.(type) {
+	case map[string]any:
+		return string(Object)
+	case []any:
+		return string(Array)
+	case string:
+		return string(String)
+	case bool:
+		return string(Boolean)
+	case nil:
+		return string(Null)
+	case float64, json.Number:
+		if hasType(value, Integer) {
+			return string(Integer)
+		}
+		return string(Number)
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func toFloat(value any) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case json.Number:
+		f, _ := value.Float64()
+		return f
+	}
+	return math.NaN()
+}
+
+// equal compares JSON values, numbers are equal when their values are equal.
+func equal(a, b any) bool {
+	a, b = normalize(a), normalize(b)
+	return reflect.DeepEqual(a, b)
+}
+
+// normalize converts numbers to float64, recursively.
+func normalize(value any) any {
+	switch value := value.(type) {
+	case json.Number:
+		return toFloat(value)
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case float32:
+		return float64(value)
+	case map[string]any:
+		normalized := make(map[string]any, len(value))
+		for k, v := range value {
+			normalized[k] = normalize(v)
+		}
+		return normalized
+	case []any:
+		normalized := make([]any, len(value))
+		for i, v := range value {
+			normalized[i] = normalize(v)
+		}
+		return normalized
+	}
+	return value
+}
+
+func formatValue(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(b)
+}
+
+func propertyPath(path, name string) string {
+	if isIdentifier(name) {
+		return path + "." + name
+	}
+	return path + "[" + strconv.Quote(name) + "]"
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+var userSchema = jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"name": {Type: jsonschema.String, MinLength: intPtr(1), MaxLength: intPtr(8)},
+		"age":  {Type: jsonschema.Integer, Minimum: float64Ptr(0)},
+		"role": {Type: jsonschema.String, Enum: []string{"admin", "user"}},
+		"tags": {
+			Type:        jsonschema.Array,
+			Items:       &jsonschema.Definition{Type: jsonschema.String, Pattern: "^[a-z]+$"},
+			MaxItems:    intPtr(2),
+			UniqueItems: true,
+		},
+		"address": {Ref: "#/$defs/address"},
+		"email":   {Type: jsonschema.String, Nullable: true},
+		"level":   {Type: jsonschema.String, Enum: []string{"junior", "senior"}, Nullable: true},
+	},
+	Required:             []string{"name", "age"},
+	AdditionalProperties: false,
+	Defs: map[string]jsonschema.Definition{
+		"address": {
+			Type: jsonschema.Object,
+			Properties: map[string]jsonschema.Definition{
+				"city": {Type: jsonschema.String},
+			},
+			Required: []string{"city"},
+		},
+	},
+}
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     jsonschema.ValidationErrors
+	}{
+		{
+			name: "valid",
+			document: `{
+				"name": "alice", "age": 30, "role": "admin", "tags": ["a", "b"],
+				"address": {"city": "Boston"}, "email": null, "level": null
+			}`,
+		},
+		{
+			name:     "wrong type",
+			document: `[]`,
+			want:     jsonschema.ValidationErrors{{Path: "$", Message: "expected object, got array"}},
+		},
+		{
+			name:     "missing and unknown properties",
+			document: `{"nickname": "al"}`,
+			want: jsonschema.ValidationErrors{
+				{Path: "$.name", Message: "is required"},
+				{Path: "$.age", Message: "is required"},
+				{Path: "$.nickname", Message: "is not allowed"},
+			},
+		},
+		{
+			name: "nested values",
+			document: `{
+				"name": "", "age": 1.5, "role": "root", "tags": ["A", "b", "b"],
+				"address": {}, "email": 1, "level": "lead"
+			}`,
+			want: jsonschema.ValidationErrors{
+				{Path: "$.address.city", Message: "is required"},
+				{Path: "$.age", Message: "expected integer, got number"},
+				{Path: "$.email", Message: "expected string or null, got integer"},
+				{Path: "$.level", Message: `must be one of "junior", "senior"`},
+				{Path: "$.name", Message: "must be at least 1 characters long, got 0"},
+				{Path: "$.role", Message: `must be one of "admin", "user"`},
+				{Path: "$.tags", Message: "must have at most 2 items, got 3"},
+				{Path: "$.tags", Message: "items 1 and 2 must be unique"},
+				{Path: "$.tags[0]", Message: "must match pattern ^[a-z]+$"},
+			},
+		},
+		{
+			name:     "bounds",
+			document: `{"name": "a very long name", "age": -1}`,
+			want: jsonschema.ValidationErrors{
+				{Path: "$.age", Message: "must be greater than or equal to 0"},
+				{Path: "$.name", Message: "must be at most 8 characters long, got 16"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := jsonschema.ValidateJSON(userSchema, []byte(tt.document))
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("ValidateJSON() error = %v", err)
+				}
+				return
+			}
+
+			var errs jsonschema.ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateJSON() error = %v, want ValidationErrors", err)
+			}
+			if !reflect.DeepEqual(errs, tt.want) {
+				t.Fatalf("ValidateJSON() errors = %q, want %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCombinations(t *testing.T) {
+	schema := jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"id": {AnyOf: []jsonschema.Definition{{Type: jsonschema.String}, {Type: jsonschema.Integer}}},
+			"amount": {
+				OneOf: []jsonschema.Definition{
+					{Type: jsonschema.Integer},
+					{Type: jsonschema.Number, MultipleOf: float64Ptr(0.5)},
+				},
+			},
+			"version": {Const: 2},
+			"level":   {EnumValues: []any{1, 2, true}},
+			"ratio": {
+				AllOf: []jsonschema.Definition{
+					{Type: jsonschema.Number, ExclusiveMinimum: float64Ptr(0)},
+					{Type: jsonschema.Number, ExclusiveMaximum: float64Ptr(1)},
+				},
+			},
+			"labels": {Type: jsonschema.Object, AdditionalProperties: jsonschema.Definition{Type: jsonschema.String}},
+		},
+	}
+
+	valid := `{"id": 1, "amount": 1.5, "version": 2, "level": true, "ratio": 0.5, "labels": {"a": "b"}}`
+	err := jsonschema.ValidateJSON(schema, []byte(valid))
+	if err != nil {
+		t.Fatalf("ValidateJSON() error = %v", err)
+	}
+
+	invalid := `{"id": true, "amount": 2, "version": 3, "level": 3, "ratio": 1, "labels": {"a": 1}}`
+	err = jsonschema.ValidateJSON(schema, []byte(invalid))
+	var errs jsonschema.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("ValidateJSON() error = %v, want ValidationErrors", err)
+	}
+
+	paths := make([]string, len(errs))
+	for i, e := range errs {
+		paths[i] = e.Path
+	}
+	want := []string{"$.amount", "$.id", "$.labels.a", "$.level", "$.ratio", "$.version"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("ValidateJSON() errors = %v, want errors at %v", errs, want)
+	}
+}
+
+func TestValidateJSONInvalidDocument(t *testing.T) {
+	err := jsonschema.ValidateJSON(userSchema, []byte(`{"name": `))
+	var errs jsonschema.ValidationErrors
+	if err == nil || errors.As(err, &errs) {
+		t.Fatalf("ValidateJSON() error = %v, want a decoding error", err)
+	}
+
+	err = jsonschema.ValidateJSON(jsonschema.Definition{}, []byte(`{} {}`))
+	if err == nil {
+		t.Fatalf("ValidateJSON() should fail for several documents")
+	}
+}
+
+func TestValidateGeneratedSchema(t *testing.T) {
+	type arguments struct {
+		Location string `json:"location"`
+		Unit     string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+		Days     *int   `json:"days"`
+	}
+	schema, err := jsonschema.GenerateSchemaForType(arguments{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType() error = %v", err)
+	}
+
+	err = jsonschema.ValidateJSON(*schema, []byte(`{"location": "Boston", "days": null}`))
+	if err != nil {
+		t.Fatalf("ValidateJSON() error = %v", err)
+	}
+	err = jsonschema.ValidateJSON(*schema, []byte(`{"unit": "kelvin", "days": 1}`))
+	if err == nil || err.Error() != `$.location: is required; $.unit: must be one of "celsius", "fahrenheit"` {
+		t.Fatalf("ValidateJSON() error = %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package openai
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseMetadata is the metadata of a response of the API, read from its headers. The request ID
+// identifies the request when contacting the support of OpenAI.
+type ResponseMetadata struct {
+	// RequestID is the x-request-id header, or the apim-request-id header of Azure OpenAI Service.
+	RequestID string
+	// ProcessingTime is the time the API took to process the request, read from openai-processing-ms.
+	ProcessingTime time.Duration
+	// Model is the model which served the request, read from openai-model.
+	Model string
+	// RateLimit is the state of the rate limits after the request.
+	RateLimit RateLimitHeaders
+}
+
+func newResponseMetadata(header http.Header) ResponseMetadata {
+	if header == nil {
+		return ResponseMetadata{}
+	}
+
+	metadata := ResponseMetadata{
+		RequestID: header.Get("x-request-id"),
+		Model:     header.Get("openai-model"),
+		RateLimit: newRateLimitHeaders(header),
+	}
+	if metadata.RequestID == "" {
+		metadata.RequestID = header.Get("apim-request-id")
+	}
+	if ms, err := strconv.ParseFloat(header.Get("openai-processing-ms"), 64); err == nil && ms >= 0 {
+		metadata.ProcessingTime = time.Duration(ms * float64(time.Millisecond))
+	}
+	return metadata
+}
+
+// Metadata returns the metadata of the response.
+func (h *httpHeader) Metadata() ResponseMetadata {
+	return newResponseMetadata(h.Header())
+}
+
+// ErrorMetadata returns the metadata of the response of a failed request. It returns false when
+// the error isn't an APIError or a RequestError, e.g. when the request couldn't be sent.
+func ErrorMetadata(err error) (ResponseMetadata, bool) {
+	var (
+		apiErr *APIError
+		reqErr *RequestError
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.Metadata, true
+	case errors.As(err, &reqErr):
+		return reqErr.Metadata, true
+	}
+	return ResponseMetadata{}, false
+}
@@ -0,0 +1,98 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func setMetadataHeaders(w http.ResponseWriter, requestID string) {
+	w.Header().Set("x-request-id", requestID)
+	w.Header().Set("openai-processing-ms", "1250")
+	w.Header().Set("openai-model", "gpt-3.5-turbo-0125")
+	w.Header().Set("x-ratelimit-remaining-requests", "99")
+}
+
+func TestResponseMetadata(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		setMetadataHeaders(w, "req-1")
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{"id":"chatcmpl-1","choices":[]}`))
+		checks.NoError(t, err, "Write error")
+	})
+
+	response, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	want := openai.ResponseMetadata{
+		RequestID:      "req-1",
+		ProcessingTime: 1250 * time.Millisecond,
+		Model:          "gpt-3.5-turbo-0125",
+		RateLimit:      openai.RateLimitHeaders{RemainingRequests: 99},
+	}
+	if metadata := response.Metadata(); metadata != want {
+		t.Fatalf("Metadata() = %+v, want %+v", metadata, want)
+	}
+}
+
+func TestErrorMetadata(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		setMetadataHeaders(w, "req-2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, err := w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests"}}`))
+		checks.NoError(t, err, "Write error")
+	})
+	server.RegisterHandler("/v1/engines", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("apim-request-id", "req-3")
+		w.WriteHeader(http.StatusBadGateway)
+		_, err := w.Write([]byte(`<html>Bad Gateway</html>`))
+		checks.NoError(t, err, "Write error")
+	})
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		setMetadataHeaders(w, "req-4")
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(`data: {"error":{"message":"The server had an error","type":"server_error"}}` + "\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	_, err := client.ListModels(context.Background())
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.Metadata.RequestID != "req-2" ||
+		apiErr.Metadata.ProcessingTime != 1250*time.Millisecond || apiErr.Metadata.RateLimit.RemainingRequests != 99 {
+		t.Fatalf("ListModels() error = %+v, want an APIError with the metadata of the response", err)
+	}
+
+	_, err = client.ListEngines(context.Background())
+	metadata, ok := openai.ErrorMetadata(err)
+	if !ok || metadata.RequestID != "req-3" {
+		t.Fatalf("ErrorMetadata() = %+v, %t, want the request ID of the RequestError", metadata, ok)
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	_, err = stream.Recv()
+	metadata, ok = openai.ErrorMetadata(err)
+	if !ok || metadata.RequestID != "req-4" || metadata.Model != "gpt-3.5-turbo-0125" {
+		t.Fatalf("ErrorMetadata() = %+v, %t, want the metadata of the stream", metadata, ok)
+	}
+
+	if _, ok = openai.ErrorMetadata(context.Canceled); ok {
+		t.Fatal("ErrorMetadata() returned metadata for an error without response")
+	}
+}
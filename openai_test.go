@@ -6,13 +6,23 @@ import (
 )
 
 func setupOpenAITestServer() (client *openai.Client, server *test.ServerTest, teardown func()) {
+	return setupOpenAITestServerWithConfig(func(config *openai.ClientConfig) {
+		config.EnableRateLimiter = true
+	})
+}
+
+// setupOpenAITestServerWithConfig starts a test server and returns a client of the server,
+// configured by configure.
+func setupOpenAITestServerWithConfig(
+	configure func(*openai.ClientConfig),
+) (client *openai.Client, server *test.ServerTest, teardown func()) {
 	server = test.NewTestServer()
 	ts := server.OpenAITestServer()
 	ts.Start()
 	teardown = ts.Close
 	config := openai.DefaultConfig(test.GetTestToken())
 	config.BaseURL = ts.URL + "/v1"
-	config.EnableRateLimiter = true
+	configure(&config)
 	client = openai.NewClientWithConfig(config)
 	return
 }
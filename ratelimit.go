@@ -27,6 +27,16 @@ func (r ResetTime) Time() time.Time {
 	return time.Now().Add(d)
 }
 
+// Duration returns the time left until the limit resets.
+// The second return value is false if the value can't be parsed.
+func (r ResetTime) Duration() (time.Duration, bool) {
+	d, err := time.ParseDuration(string(r))
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
 func newRateLimitHeaders(h http.Header) RateLimitHeaders {
 	limitReq, _ := strconv.Atoi(h.Get("x-ratelimit-limit-requests"))
 	limitTokens, _ := strconv.Atoi(h.Get("x-ratelimit-limit-tokens"))
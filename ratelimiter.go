@@ -80,7 +80,18 @@ func NewMemRateLimiter(apiType APIType) *MemRateLimiter {
 	return r
 }
 
-func (r *MemRateLimiter) WaitForRequest(ctx context.Context, model string, req TokenCountable) (err error) {
+func (r *MemRateLimiter) WaitForRequest(ctx context.Context, model string, req TokenCountable) error {
+	return waitForRequest(ctx, model, req, r.Wait)
+}
+
+// waitForRequest implements RateLimiter.WaitForRequest for the rate limiters, it counts the
+// tokens of the request and waits for them with wait.
+func waitForRequest(
+	ctx context.Context,
+	model string,
+	req TokenCountable,
+	wait func(ctx context.Context, model string, tokens int) error,
+) (err error) {
 	if ctx == nil {
 		return fmt.Errorf("context is nil")
 	}
@@ -96,7 +107,7 @@ func (r *MemRateLimiter) WaitForRequest(ctx context.Context, model string, req T
 		return
 	}
 
-	err = r.Wait(ctx, model, tokens)
+	err = wait(ctx, model, tokens)
 	if err != nil {
 		err = fmt.Errorf("failed to wait for rate limiter: %w", err)
 		return
@@ -182,62 +193,91 @@ func (r *MemRateLimiter) newLimiter(minuteRate int) *rate.Limiter {
 	return rate.NewLimiter(rate.Limit(minuteRate/SecondsPerMinute), minuteRate)
 }
 
+// newLimiters creates a map of limiters from a map of per minute limits.
+func (r *MemRateLimiter) newLimiters(limits map[string]int) map[string]*rate.Limiter {
+	limiters := make(map[string]*rate.Limiter, len(limits))
+	for model, limit := range limits {
+		limiters[model] = r.newLimiter(limit)
+	}
+	return limiters
+}
+
 // newAzureRequestLimiters creates a map of request limiters for each azure openai model.
 func (r *MemRateLimiter) newAzureRequestLimiters() map[string]*rate.Limiter {
-	// The limiters that are not defined here are controlled by the DefaultRequestLimit.
-	return map[string]*rate.Limiter{
-		GPT3Davinci:       r.newLimiter(AzureDavinciRequestLimitPerMinute),
-		GPT3Dot5Turbo:     r.newLimiter(AzureChatGPTRequestLimitPerMinute),
-		GPT3Dot5Turbo0301: r.newLimiter(AzureChatGPTRequestLimitPerMinute),
-		GPT4:              r.newLimiter(AzureGPT4RequestLimitPerMinute),
-		GPT432K:           r.newLimiter(AzureGPT432kTokensLimitPerMinute),
-	}
+	return r.newLimiters(azureRequestLimits())
 }
 
 // newAzureTokensLimiters creates a map of tokens limiters for each azure openai model.
 func (r *MemRateLimiter) newAzureTokensLimiters() map[string]*rate.Limiter {
-	// The limiters that are not defined here are controlled by the DefaultTokensLimit.
-	return map[string]*rate.Limiter{
-		GPT3Davinci:       r.newLimiter(AzureDavinciTokensLimitPerMinute),
-		GPT3Dot5Turbo:     r.newLimiter(AzureChatGPTTokensLimitPerMinute),
-		GPT3Dot5Turbo0301: r.newLimiter(AzureChatGPTTokensLimitPerMinute),
-		GPT4:              r.newLimiter(AzureGPT4TokensLimitPerMinute),
-		GPT432K:           r.newLimiter(AzureGPT432kTokensLimitPerMinute),
-	}
+	return r.newLimiters(azureTokensLimits())
 }
 
 // newOpenAIRequestLimiters creates a map of request limiters for each openai model.
 func (r *MemRateLimiter) newOpenAIRequestLimiters() map[string]*rate.Limiter {
-	// The limiters that are not defined here are controlled by the DefaultRequestLimit.
-	return map[string]*rate.Limiter{
-		GPT3Davinci:          r.newLimiter(OpenAITextAndEmbeddingRequestLimitPerMinute),
-		GPT3Dot5Turbo:        r.newLimiter(OpenAIChatRequestLimitPerMinute),
-		GPT3Dot5Turbo0301:    r.newLimiter(OpenAIChatRequestLimitPerMinute),
-		GPT3Dot5Turbo0613:    r.newLimiter(OpenAIChatRequestLimitPerMinute),
-		GPT3Dot5Turbo16K:     r.newLimiter(OpenAIGPT3Dot5Turbo16kRequestLimitPerMinute),
-		GPT3Dot5Turbo16K0613: r.newLimiter(OpenAIGPT3Dot5Turbo16kRequestLimitPerMinute),
-		GPT4:                 r.newLimiter(OpenAIGPT4RequestLimitPerMinute),
-		GPT40314:             r.newLimiter(OpenAIGPT4RequestLimitPerMinute),
-		GPT432K:              r.newLimiter(OpenAIGPT432kRequestLimitPerMinute),
-		GPT432K0314:          r.newLimiter(OpenAIGPT432kRequestLimitPerMinute),
-		GPT4TurboPreview:     r.newLimiter(OpenAIGPT4TurboRequestLimitPerMinute),
-		GPT3Whisper1:         r.newLimiter(OpenAIAudioRequestLimitPerMinute),
-	}
+	return r.newLimiters(openAIRequestLimits())
 }
 
 // newOpenAITokensLimiters creates a map of tokens limiters for each openai model.
 func (r *MemRateLimiter) newOpenAITokensLimiters() map[string]*rate.Limiter {
-	// The limiters that are not defined here are controlled by the DefaultTokensLimit.
-	return map[string]*rate.Limiter{
-		GPT3Davinci:            r.newLimiter(OpenAIDavinciTokensLimitPerMinute),
-		GPT3TextAdaEmbeddingV2: r.newLimiter(OpenAIAdaTokensLimitPerMinute),
-		GPT3Dot5Turbo:          r.newLimiter(OpenAIChatTokensLimitPerMinute),
-		GPT3Dot5Turbo0301:      r.newLimiter(OpenAIChatTokensLimitPerMinute),
-		GPT3Dot5Turbo0613:      r.newLimiter(OpenAIChatRequestLimitPerMinute),
-		GPT3Dot5Turbo16K:       r.newLimiter(OpenAIGPT3Dot5Turbo16kTokensLimitPerMinute),
-		GPT3Dot5Turbo16K0613:   r.newLimiter(OpenAIGPT3Dot5Turbo16kTokensLimitPerMinute),
-		GPT4:                   r.newLimiter(OpenAIGPT4TokensLimitPerMinute),
-		GPT432K:                r.newLimiter(OpenAIGPT432kTokensLimitPerMinute),
-		GPT4TurboPreview:       r.newLimiter(OpenAIGPT4TurboTokensLimitPerMinute),
+	return r.newLimiters(openAITokensLimits())
+}
+
+// azureRequestLimits returns the per minute request limits of azure openai models.
+func azureRequestLimits() map[string]int {
+	// The limits that are not defined here are controlled by the DefaultRequestLimit.
+	return map[string]int{
+		GPT3Davinci:       AzureDavinciRequestLimitPerMinute,
+		GPT3Dot5Turbo:     AzureChatGPTRequestLimitPerMinute,
+		GPT3Dot5Turbo0301: AzureChatGPTRequestLimitPerMinute,
+		GPT4:              AzureGPT4RequestLimitPerMinute,
+		GPT432K:           AzureGPT432kTokensLimitPerMinute,
+	}
+}
+
+// azureTokensLimits returns the per minute tokens limits of azure openai models.
+func azureTokensLimits() map[string]int {
+	// The limits that are not defined here are controlled by the DefaultTokensLimit.
+	return map[string]int{
+		GPT3Davinci:       AzureDavinciTokensLimitPerMinute,
+		GPT3Dot5Turbo:     AzureChatGPTTokensLimitPerMinute,
+		GPT3Dot5Turbo0301: AzureChatGPTTokensLimitPerMinute,
+		GPT4:              AzureGPT4TokensLimitPerMinute,
+		GPT432K:           AzureGPT432kTokensLimitPerMinute,
+	}
+}
+
+// openAIRequestLimits returns the per minute request limits of openai models.
+func openAIRequestLimits() map[string]int {
+	// The limits that are not defined here are controlled by the DefaultRequestLimit.
+	return map[string]int{
+		GPT3Davinci:          OpenAITextAndEmbeddingRequestLimitPerMinute,
+		GPT3Dot5Turbo:        OpenAIChatRequestLimitPerMinute,
+		GPT3Dot5Turbo0301:    OpenAIChatRequestLimitPerMinute,
+		GPT3Dot5Turbo0613:    OpenAIChatRequestLimitPerMinute,
+		GPT3Dot5Turbo16K:     OpenAIGPT3Dot5Turbo16kRequestLimitPerMinute,
+		GPT3Dot5Turbo16K0613: OpenAIGPT3Dot5Turbo16kRequestLimitPerMinute,
+		GPT4:                 OpenAIGPT4RequestLimitPerMinute,
+		GPT40314:             OpenAIGPT4RequestLimitPerMinute,
+		GPT432K:              OpenAIGPT432kRequestLimitPerMinute,
+		GPT432K0314:          OpenAIGPT432kRequestLimitPerMinute,
+		GPT4TurboPreview:     OpenAIGPT4TurboRequestLimitPerMinute,
+		GPT3Whisper1:         OpenAIAudioRequestLimitPerMinute,
+	}
+}
+
+// openAITokensLimits returns the per minute tokens limits of openai models.
+func openAITokensLimits() map[string]int {
+	// The limits that are not defined here are controlled by the DefaultTokensLimit.
+	return map[string]int{
+		GPT3Davinci:            OpenAIDavinciTokensLimitPerMinute,
+		GPT3TextAdaEmbeddingV2: OpenAIAdaTokensLimitPerMinute,
+		GPT3Dot5Turbo:          OpenAIChatTokensLimitPerMinute,
+		GPT3Dot5Turbo0301:      OpenAIChatTokensLimitPerMinute,
+		GPT3Dot5Turbo0613:      OpenAIChatRequestLimitPerMinute,
+		GPT3Dot5Turbo16K:       OpenAIGPT3Dot5Turbo16kTokensLimitPerMinute,
+		GPT3Dot5Turbo16K0613:   OpenAIGPT3Dot5Turbo16kTokensLimitPerMinute,
+		GPT4:                   OpenAIGPT4TokensLimitPerMinute,
+		GPT432K:                OpenAIGPT432kTokensLimitPerMinute,
+		GPT4TurboPreview:       OpenAIGPT4TurboTokensLimitPerMinute,
 	}
 }
@@ -0,0 +1,54 @@
+//go:build integration
+
+package openai_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+// TestRedisRateLimitStoreScript runs the script of the store on a real Redis server,
+// the unit tests only use a fake server.
+func TestRedisRateLimitStoreScript(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("Skipping testing against a Redis server. Set REDIS_ADDR environment variable to enable it.")
+	}
+
+	store := openai.NewRedisRateLimitStore(openai.RedisRateLimitStoreConfig{Addr: addr})
+	defer store.Close()
+	ctx := context.Background()
+	key := "openai:ratelimit:test:" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	// the bucket starts full with 5 tokens and gains 5 tokens per minute
+	wait, err := store.TakeTokens(ctx, key, 3, 5, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait != 0 {
+		t.Fatalf("TakeTokens() wait = %v, want 0", wait)
+	}
+
+	// 2 tokens are left, the missing token takes 12s
+	wait, err = store.TakeTokens(ctx, key, 3, 5, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait < 11*time.Second || wait > 12*time.Second {
+		t.Fatalf("TakeTokens() wait = %v, want about 12s", wait)
+	}
+
+	// nothing was taken by the failed call
+	wait, err = store.TakeTokens(ctx, key, 2, 5, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait != 0 {
+		t.Fatalf("TakeTokens() wait = %v, want 0", wait)
+	}
+	wait, err = store.TakeTokens(ctx, key, 1, 5, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait <= 0 || wait > 12*time.Second {
+		t.Fatalf("TakeTokens() wait = %v, want at most 12s", wait)
+	}
+}
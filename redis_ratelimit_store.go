@@ -0,0 +1,175 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	utils "github.com/sashabaranov/go-openai/internal"
+)
+
+const defaultRedisPoolSize = 10
+
+// takeTokensScript implements RateLimitStore.TakeTokens as an atomic Redis script.
+// Buckets are stored as hashes with the amount of tokens and the time of the last
+// refill in milliseconds, taken from the server clock so that clients don't need
+// synchronized clocks.
+//
+// Scripts calling TIME before writing must be replicated by effects, which is the
+// default since Redis 5. The script enables it for Redis 3.2 and 4, older servers
+// aren't supported. HMSET is used rather than HSET, which only sets several fields
+// since Redis 4. The unit tests of the store use a fake server mirroring the script
+// with MemRateLimitStore, the script itself is run by the integration tests when
+// the REDIS_ADDR environment variable is set.
+const takeTokensScript = `
+redis.replicate_commands()
+local n = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local interval = tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = capacity
+	updated = now
+end
+local perMillisecond = capacity / interval
+if now > updated then
+	tokens = math.min(capacity, tokens + (now - updated) * perMillisecond)
+	updated = now
+end
+local wait = 0
+if tokens >= n then
+	tokens = tokens - n
+else
+	wait = math.ceil((n - tokens) / perMillisecond)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(updated))
+redis.call('PEXPIRE', KEYS[1], interval * 2)
+return wait
+`
+
+// RedisRateLimitStoreConfig is a configuration of a RedisRateLimitStore.
+type RedisRateLimitStoreConfig struct {
+	// Addr is the host:port address of the Redis server.
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	// PoolSize is the maximum number of idle connections kept open, defaults to 10.
+	PoolSize int
+	// Dial is used to open connections, defaults to net.Dialer.DialContext.
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// RedisRateLimitStore is a RateLimitStore which keeps the buckets in Redis 3.2 or newer,
+// or any server speaking the Redis protocol and supporting EVAL.
+type RedisRateLimitStore struct {
+	config RedisRateLimitStoreConfig
+	pool   chan *utils.RedisConn
+}
+
+func NewRedisRateLimitStore(config RedisRateLimitStoreConfig) *RedisRateLimitStore {
+	if config.PoolSize <= 0 {
+		config.PoolSize = defaultRedisPoolSize
+	}
+	if config.Dial == nil {
+		config.Dial = (&net.Dialer{}).DialContext
+	}
+
+	return &RedisRateLimitStore{
+		config: config,
+		pool:   make(chan *utils.RedisConn, config.PoolSize),
+	}
+}
+
+// TakeTokens implements RateLimitStore.
+func (s *RedisRateLimitStore) TakeTokens(
+	ctx context.Context,
+	key string,
+	n int,
+	capacity int,
+	interval time.Duration,
+) (time.Duration, error) {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	reply, err := conn.Do(ctx, "EVAL", takeTokensScript, "1", key,
+		strconv.Itoa(n), strconv.Itoa(capacity), strconv.FormatInt(interval.Milliseconds(), 10))
+	s.release(conn, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to take tokens from redis: %w", err)
+	}
+
+	wait, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("failed to take tokens from redis: unexpected reply %v", reply)
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// Close closes the idle connections of the store.
+func (s *RedisRateLimitStore) Close() error {
+	for {
+		select {
+		case conn := <-s.pool:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (s *RedisRateLimitStore) conn(ctx context.Context) (*utils.RedisConn, error) {
+	select {
+	case conn := <-s.pool:
+		return conn, nil
+	default:
+	}
+
+	netConn, err := s.config.Dial(ctx, "tcp", s.config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	conn := utils.NewRedisConn(netConn)
+	if s.config.Password != "" {
+		args := []string{"AUTH", s.config.Password}
+		if s.config.Username != "" {
+			args = []string{"AUTH", s.config.Username, s.config.Password}
+		}
+		if _, err = conn.Do(ctx, args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate to redis: %w", err)
+		}
+	}
+
+	if s.config.DB != 0 {
+		if _, err = conn.Do(ctx, "SELECT", strconv.Itoa(s.config.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select redis database: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+// release returns the connection to the pool, unless it failed with an I/O error.
+func (s *RedisRateLimitStore) release(conn *utils.RedisConn, err error) {
+	if _, isReply := err.(utils.RedisError); err != nil && !isReply {
+		conn.Close()
+		return
+	}
+
+	select {
+	case s.pool <- conn:
+	default:
+		conn.Close()
+	}
+}
@@ -0,0 +1,175 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 30 * time.Second
+	defaultRetryMultiplier     = 2.0
+	defaultRetryJitter         = 0.2
+)
+
+// RetryPolicy describes how failed requests are retried by the Client.
+// The zero value disables retries: every request is sent exactly once.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values lower than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the computed exponential delay. Delays requested by the
+	// server via Retry-After or x-ratelimit-reset-* headers are not capped.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the backoff grows by after every attempt.
+	Multiplier float64
+	// Jitter is the fraction (0..1) of the backoff that is randomized.
+	Jitter float64
+	// RetryableStatusCodes lists the HTTP status codes which are retried.
+	RetryableStatusCodes []int
+	// RetryableErrorTypes lists the APIError.Type values which are retried
+	// regardless of the HTTP status code.
+	RetryableErrorTypes []string
+	// NonRetryableErrorTypes lists the APIError.Type values which are never
+	// retried, e.g. "insufficient_quota" which is reported with status 429.
+	NonRetryableErrorTypes []string
+	// RetryOnNetworkError enables retries of transport errors returned by HTTPClient.
+	RetryOnNetworkError bool
+}
+
+// DefaultRetryPolicy returns a retry policy suitable for the OpenAI API.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    defaultRetryMaxAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+		Multiplier:     defaultRetryMultiplier,
+		Jitter:         defaultRetryJitter,
+		RetryableStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusConflict,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableErrorTypes:    []string{"server_error"},
+		NonRetryableErrorTypes: []string{"insufficient_quota"},
+		RetryOnNetworkError:    true,
+	}
+}
+
+func (p RetryPolicy) enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+// isRetryableError reports whether err, returned by handleErrorResp or HTTPClient.Do, should be retried.
+func (p RetryPolicy) isRetryableError(err error, statusCode int) bool {
+	var (
+		errType string
+		apiErr  *APIError
+		reqErr  *RequestError
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		errType = apiErr.Type
+	case errors.As(err, &reqErr):
+	default:
+		return p.RetryOnNetworkError
+	}
+
+	if contains(p.NonRetryableErrorTypes, errType) {
+		return false
+	}
+	return contains(p.RetryableStatusCodes, statusCode) ||
+		(errType != "" && contains(p.RetryableErrorTypes, errType))
+}
+
+// backoff returns the delay before the given retry attempt (starting at 1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		d = d*(1-jitter) + d*jitter*rand.Float64() //nolint:gosec // jitter doesn't need a secure source
+	}
+	return time.Duration(d)
+}
+
+// retryAfter returns the delay requested by the server for the given response, if any.
+// Retry-After (seconds or HTTP date) and retry-after-ms take precedence over
+// the x-ratelimit-reset-* headers, which are only used for exhausted limits.
+func retryAfter(header http.Header) (time.Duration, bool) {
+	if header == nil {
+		return 0, false
+	}
+
+	if v := header.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+
+	if v := header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+		if date, err := http.ParseTime(v); err == nil {
+			d := time.Until(date)
+			if d < 0 {
+				d = 0
+			}
+			return d, true
+		}
+	}
+
+	var (
+		delay time.Duration
+		found bool
+	)
+	rateLimit := newRateLimitHeaders(header)
+	if header.Get("x-ratelimit-remaining-requests") != "" && rateLimit.RemainingRequests == 0 {
+		if d, ok := rateLimit.ResetRequests.Duration(); ok && d >= delay {
+			delay, found = d, true
+		}
+	}
+	if header.Get("x-ratelimit-remaining-tokens") != "" && rateLimit.RemainingTokens == 0 {
+		if d, ok := rateLimit.ResetTokens.Duration(); ok && d >= delay {
+			delay, found = d, true
+		}
+	}
+	return delay, found
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,169 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func setupRetryTestServer(policy openai.RetryPolicy) (client *openai.Client, server *test.ServerTest, teardown func()) {
+	return setupOpenAITestServerWithConfig(func(config *openai.ClientConfig) {
+		config.RetryPolicy = policy
+	})
+}
+
+func fastRetryPolicy() openai.RetryPolicy {
+	policy := openai.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestRetryOnServerError(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(fastRetryPolicy())
+	defer teardown()
+
+	var attempts int32
+	server.RegisterHandler("/v1/models/text-davinci-003", func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":{"message":"That model is currently overloaded","type":"server_error"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"text-davinci-003"}`)
+	})
+
+	model, err := client.GetModel(context.Background(), "text-davinci-003")
+	checks.NoError(t, err, "GetModel error")
+	if model.ID != "text-davinci-003" {
+		t.Fatalf("unexpected model: %s", model.ID)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(fastRetryPolicy())
+	defer teardown()
+
+	var attempts int32
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"error":{"message":"boom","type":"server_error"}}`)
+	})
+
+	_, err := client.ListModels(context.Background())
+	apiErr := &openai.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected APIError with status 500, got %v", err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestRetrySkipsNonRetryableErrors(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(fastRetryPolicy())
+	defer teardown()
+
+	var attempts int32
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota"}}`)
+	})
+
+	_, err := client.ListModels(context.Background())
+	checks.HasError(t, err, "ListModels should fail")
+	if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Fatalf("expected 1 attempt, got %d", n)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	policy := fastRetryPolicy()
+	policy.MaxBackoff = time.Millisecond
+	client, server, teardown := setupRetryTestServer(policy)
+	defer teardown()
+
+	const wait = 50 * time.Millisecond
+	var attempts int32
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("retry-after-ms", "50")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"requests"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":[]}`)
+	})
+
+	start := time.Now()
+	_, err := client.ListModels(context.Background())
+	checks.NoError(t, err, "ListModels error")
+	if elapsed := time.Since(start); elapsed < wait {
+		t.Fatalf("expected to wait at least %s, waited %s", wait, elapsed)
+	}
+}
+
+func TestRetryReplaysMultipartBody(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(fastRetryPolicy())
+	defer teardown()
+
+	var attempts int32
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		attempt := atomic.AddInt32(&attempts, 1)
+		body, err := io.ReadAll(r.Body)
+		checks.NoError(t, err, "read body error")
+		if !strings.Contains(string(body), "hello world") {
+			t.Errorf("attempt %d: body doesn't contain the file content", attempt)
+		}
+		if attempt == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"id":"file-abc"}`)
+	})
+
+	file, err := client.CreateFileBytes(context.Background(), openai.FileBytesRequest{
+		Name:    "foo.jsonl",
+		Bytes:   []byte("hello world"),
+		Purpose: openai.PurposeFineTune,
+	})
+	checks.NoError(t, err, "CreateFileBytes error")
+	if n := atomic.LoadInt32(&attempts); file.ID != "file-abc" || n != 2 {
+		t.Fatalf("unexpected result: id %q after %d attempts", file.ID, n)
+	}
+}
+
+func TestRetryStopsOnContextCancel(t *testing.T) {
+	policy := fastRetryPolicy()
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+	client, server, teardown := setupRetryTestServer(policy)
+	defer teardown()
+
+	server.RegisterHandler("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.ListModels(ctx)
+	reqErr := &openai.RequestError{}
+	if !errors.As(err, &reqErr) || reqErr.HTTPStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the last RequestError, got %v", err)
+	}
+}
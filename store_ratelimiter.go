@@ -0,0 +1,187 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitStore keeps token buckets which can be shared between processes.
+type RateLimitStore interface {
+	// TakeTokens atomically refills the bucket identified by key, which holds up to
+	// capacity tokens and gains capacity tokens per interval, and removes n tokens
+	// from it. When the bucket holds less than n tokens nothing is removed and the
+	// time until enough tokens are available is returned.
+	TakeTokens(ctx context.Context, key string, n, capacity int, interval time.Duration) (time.Duration, error)
+}
+
+// StoreRateLimiter is a token bucket based rate limiter for OpenAI API which keeps
+// its buckets in a RateLimitStore, so that several clients sharing the same
+// organization can share the same limits.
+type StoreRateLimiter struct {
+	store RateLimitStore
+	// KeyPrefix is prepended to the keys of the buckets in the store.
+	KeyPrefix string
+
+	mutex sync.RWMutex
+	// RequestLimits and TokensLimits hold the per minute limits of each model.
+	// A limit of zero means that the model is not rate limited.
+	RequestLimits       map[string]int
+	TokensLimits        map[string]int
+	DefaultRequestLimit int
+	DefaultTokensLimit  int
+}
+
+func NewStoreRateLimiter(apiType APIType, store RateLimitStore) *StoreRateLimiter {
+	r := &StoreRateLimiter{
+		store:               store,
+		KeyPrefix:           "openai:ratelimit:",
+		DefaultRequestLimit: AzureDefaultRequestLimitPerMinute,
+		DefaultTokensLimit:  AzureDefaultTokensLimitPerMinute,
+	}
+
+	if apiType == APITypeAzure || apiType == APITypeAzureAD {
+		r.RequestLimits = azureRequestLimits()
+		r.TokensLimits = azureTokensLimits()
+	}
+
+	if apiType == APITypeOpenAI {
+		r.RequestLimits = openAIRequestLimits()
+		r.TokensLimits = openAITokensLimits()
+		r.DefaultRequestLimit = OpenAIDefaultRequestLimitPerMinute
+		r.DefaultTokensLimit = OpenAIDefaultTokensLimitPerMinute
+	}
+
+	return r
+}
+
+func (r *StoreRateLimiter) WaitForRequest(ctx context.Context, model string, req TokenCountable) error {
+	return waitForRequest(ctx, model, req, r.Wait)
+}
+
+func (r *StoreRateLimiter) Wait(ctx context.Context, model string, tokens int) (err error) {
+	err = r.wait(ctx, r.KeyPrefix+"requests:"+model, 1, r.limit(r.RequestLimits, model, r.DefaultRequestLimit))
+	if err != nil {
+		return err
+	}
+
+	if tokens == 0 {
+		return nil
+	}
+
+	return r.wait(ctx, r.KeyPrefix+"tokens:"+model, tokens, r.limit(r.TokensLimits, model, r.DefaultTokensLimit))
+}
+
+func (r *StoreRateLimiter) limit(limits map[string]int, model string, defaultLimit int) int {
+	if limits == nil {
+		return 0
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	limit, ok := limits[model]
+	if !ok {
+		return defaultLimit
+	}
+	return limit
+}
+
+func (r *StoreRateLimiter) wait(ctx context.Context, key string, n, minuteRate int) error {
+	// if limit is zero, it means that the model is not rate limited
+	if minuteRate <= 0 {
+		return nil
+	}
+
+	if n > minuteRate {
+		return fmt.Errorf("rate: Wait(n=%d) exceeds limiter's burst %d", n, minuteRate)
+	}
+
+	for {
+		delay, err := r.store.TakeTokens(ctx, key, n, minuteRate, time.Minute)
+		if err != nil {
+			return err
+		}
+
+		if delay <= 0 {
+			return nil
+		}
+
+		err = sleepContext(ctx, delay)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ObserveRateLimits implements RateLimitObserver by resizing the limits of the model
+// to the limits reported by the API.
+func (r *StoreRateLimiter) ObserveRateLimits(model string, headers RateLimitHeaders) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if headers.LimitRequests > 0 && r.RequestLimits != nil {
+		r.RequestLimits[model] = headers.LimitRequests
+	}
+
+	if headers.LimitTokens > 0 && r.TokensLimits != nil {
+		r.TokensLimits[model] = headers.LimitTokens
+	}
+}
+
+// MemRateLimitStore is a RateLimitStore which keeps the buckets in memory.
+type MemRateLimitStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemRateLimitStore() *MemRateLimitStore {
+	return &MemRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// TakeTokens implements RateLimitStore.
+func (s *MemRateLimitStore) TakeTokens(
+	_ context.Context,
+	key string,
+	n int,
+	capacity int,
+	interval time.Duration,
+) (time.Duration, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(capacity), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	return bucket.take(now, n, capacity, interval), nil
+}
+
+// take refills the bucket and removes n tokens from it, or returns the time
+// until n tokens are available.
+func (b *tokenBucket) take(now time.Time, n, capacity int, interval time.Duration) time.Duration {
+	perNanosecond := float64(capacity) / float64(interval)
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(capacity), b.tokens+float64(elapsed)*perNanosecond)
+		b.updated = now
+	}
+
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		return 0
+	}
+
+	return time.Duration(math.Ceil((float64(n) - b.tokens) / perNanosecond))
+}
@@ -0,0 +1,175 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+func TestMemRateLimitStoreTakeTokens(t *testing.T) {
+	store := NewMemRateLimitStore()
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		wait, err := store.TakeTokens(ctx, "key", 1, 10, time.Minute)
+		checks.NoError(t, err, "TakeTokens error")
+		if wait != 0 {
+			t.Fatalf("TakeTokens() wait = %v, want 0", wait)
+		}
+	}
+
+	wait, err := store.TakeTokens(ctx, "key", 1, 10, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait <= 0 || wait > 6*time.Second {
+		t.Fatalf("TakeTokens() wait = %v, want about 6s", wait)
+	}
+
+	wait, err = store.TakeTokens(ctx, "other", 10, 10, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait != 0 {
+		t.Fatalf("buckets with different keys must be independent, wait = %v", wait)
+	}
+}
+
+func TestStoreRateLimiterSharesBuckets(t *testing.T) {
+	store := NewMemRateLimitStore()
+	first := NewStoreRateLimiter(APITypeOpenAI, store)
+	second := NewStoreRateLimiter(APITypeOpenAI, store)
+	for _, r := range []*StoreRateLimiter{first, second} {
+		r.RequestLimits["shared"] = 60
+	}
+
+	ctx := context.Background()
+	wg := sync.WaitGroup{}
+	for _, r := range []*StoreRateLimiter{first, second} {
+		wg.Add(1)
+		go func(r *StoreRateLimiter) {
+			defer wg.Done()
+			for i := 0; i < 30; i++ {
+				checks.NoError(t, r.Wait(ctx, "shared", 0), "Wait error")
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	wait, err := store.TakeTokens(ctx, first.KeyPrefix+"requests:shared", 1, 60, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait <= 0 {
+		t.Fatalf("the bucket shared by both limiters should be exhausted")
+	}
+}
+
+func TestStoreRateLimiterErrors(t *testing.T) {
+	r := NewStoreRateLimiter(APITypeAzure, NewMemRateLimitStore())
+
+	err := r.Wait(context.Background(), GPT3Dot5Turbo, AzureChatGPTTokensLimitPerMinute+1)
+	checks.ErrorContains(t, err, "exceeds limiter's burst", "Wait should fail when tokens exceed the limit")
+
+	r.TokensLimits["unlimited"] = 0
+	err = r.Wait(context.Background(), "unlimited", AzureChatGPTTokensLimitPerMinute*10)
+	checks.NoError(t, err, "Wait error")
+
+	r.TokensLimits[GPT4] = 10
+	r.RequestLimits[GPT4] = 100
+	checks.NoError(t, r.Wait(context.Background(), GPT4, 10), "Wait error")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = r.WaitForRequest(ctx, GPT4, EmbeddingRequest{Input: []string{"hello world"}, Model: AdaEmbeddingV2})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForRequest() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	server, err := test.NewRedisTestServer()
+	checks.NoError(t, err, "NewRedisTestServer error")
+	defer server.Close()
+
+	buckets := NewMemRateLimitStore()
+	server.RegisterHandler("EVAL", func(args []string) any {
+		// EVAL script numkeys key n capacity interval
+		if len(args) != 6 || args[1] != "1" {
+			return test.RedisError("ERR wrong number of arguments")
+		}
+		n, _ := strconv.Atoi(args[3])
+		capacity, _ := strconv.Atoi(args[4])
+		interval, _ := strconv.Atoi(args[5])
+		wait, _ := buckets.TakeTokens(context.Background(), args[2], n, capacity, time.Duration(interval)*time.Millisecond)
+		return wait.Milliseconds()
+	})
+
+	store := NewRedisRateLimitStore(RedisRateLimitStoreConfig{
+		Addr:     server.Addr(),
+		Password: "secret",
+		DB:       2,
+	})
+	defer store.Close()
+
+	ctx := context.Background()
+	wait, err := store.TakeTokens(ctx, "key", 2, 2, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait != 0 {
+		t.Fatalf("TakeTokens() wait = %v, want 0", wait)
+	}
+
+	wait, err = store.TakeTokens(ctx, "key", 1, 2, time.Minute)
+	checks.NoError(t, err, "TakeTokens error")
+	if wait < 29*time.Second || wait > 30*time.Second {
+		t.Fatalf("TakeTokens() wait = %v, want about 30s", wait)
+	}
+
+	commands := server.Commands()
+	if len(commands) != 4 || commands[0][0] != "AUTH" || commands[1][0] != "SELECT" || commands[1][1] != "2" {
+		t.Fatalf("unexpected commands, the connection should be set up once and reused: %v", commands)
+	}
+
+	server.RegisterHandler("EVAL", func([]string) any {
+		return test.RedisError("NOSCRIPT no scripting")
+	})
+	_, err = store.TakeTokens(ctx, "key", 1, 2, time.Minute)
+	checks.ErrorContains(t, err, "NOSCRIPT", "TakeTokens should return the redis error")
+}
+
+type recordingRateLimiter struct {
+	models []string
+}
+
+func (r *recordingRateLimiter) Wait(_ context.Context, model string, _ int) error {
+	r.models = append(r.models, model)
+	return nil
+}
+
+func (r *recordingRateLimiter) WaitForRequest(ctx context.Context, model string, _ TokenCountable) error {
+	return r.Wait(ctx, model, 0)
+}
+
+func TestClientUsesInjectedRateLimiter(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/chat/completions", handleChatCompletionEndpoint)
+	ts := server.OpenAITestServer()
+	ts.Start()
+	defer ts.Close()
+
+	limiter := &recordingRateLimiter{}
+	config := DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	config.RateLimiter = limiter
+	client := NewClientWithConfig(config)
+
+	_, err := client.CreateChatCompletion(context.Background(), ChatCompletionRequest{
+		MaxTokens: 5,
+		Model:     GPT3Dot5Turbo,
+		Messages:  []ChatCompletionMessage{{Role: ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if len(limiter.models) != 1 || limiter.models[0] != GPT3Dot5Turbo {
+		t.Fatalf("injected rate limiter wasn't used: %v", limiter.models)
+	}
+}
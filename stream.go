@@ -3,23 +3,26 @@ package openai
 import (
 	"context"
 	"errors"
+	"net/http"
 )
 
 var (
 	ErrTooManyEmptyStreamMessages = errors.New("stream has sent too many empty messages")
+	ErrStreamEventSkipped         = errors.New("stream event skipped")
 )
 
 type CompletionStream struct {
-	*streamReader[CompletionResponse]
+	*Stream[CompletionResponse]
 }
 
 // CreateCompletionStream — API call to create a completion w/ streaming
 // support. It sets whether to stream back partial progress. If set, tokens will be
 // sent as data-only server-sent events as they become available, with the
-// stream terminated by a data: [DONE] message.
+// stream terminated by a data: [DONE] message. The options configure the timeouts of the stream.
 func (c *Client) CreateCompletionStream(
 	ctx context.Context,
 	request CompletionRequest,
+	opts ...StreamOption,
 ) (stream *CompletionStream, err error) {
 	urlSuffix := "/completions"
 	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
@@ -38,12 +41,46 @@ func (c *Client) CreateCompletionStream(
 		return nil, err
 	}
 
-	resp, err := sendRequestStream[CompletionResponse](c, req)
+	resp, err := sendRequestStream[CompletionResponse](c, req, opts...)
 	if err != nil {
 		return
 	}
 	stream = &CompletionStream{
-		streamReader: resp,
+		Stream: resp,
 	}
 	return
 }
+
+// CreateStream sends a streaming request with the body to the endpoint at urlSuffix, e.g. "/chat/completions",
+// and returns the stream of its events, decoded by the decoder or as JSON when the decoder is nil. It allows
+// streaming from endpoints and OpenAI-compatible servers which don't have a dedicated method. The body must
+// enable streaming, e.g. with "stream": true, and is rate limited when it implements TokenCountable.
+func CreateStream[T any](
+	ctx context.Context,
+	client *Client,
+	urlSuffix string,
+	model string,
+	body any,
+	decoder StreamDecoder[T],
+	opts ...StreamOption,
+) (*Stream[T], error) {
+	if countable, ok := body.(TokenCountable); ok && client.rateLimiter != nil {
+		err := client.rateLimiter.WaitForRequest(ctx, model, countable)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := client.newRequest(ctx, http.MethodPost, client.fullURL(urlSuffix, model),
+		withBody(body), withRateLimitModel(model))
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := sendRequestStream[T](client, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	stream.decoder = decoder
+	return stream, nil
+}
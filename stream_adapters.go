@@ -0,0 +1,105 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// Channel receives the values of the stream in a goroutine and sends them to the returned channel,
+// which is closed at the end of the stream. The error channel then receives the terminal error of
+// the stream, which is nil when the stream ended normally. The stream is closed at the end of the
+// stream or when the context is done, in which case the error is the error of the context.
+func (stream *Stream[T]) Channel(ctx context.Context) (<-chan T, <-chan error) {
+	return streamChannel(ctx, stream.Recv, stream.Close)
+}
+
+// All returns an iterator over the values of the stream which yields the terminal error of the stream,
+// if any, as the last element. The stream is closed when the iteration ends, including when the loop
+// is exited early. With Go 1.23 or later it can be used in range loops:
+//
+//	for chunk, err := range stream.All() {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Print(chunk.Choices[0].Delta.Content)
+//	}
+func (stream *Stream[T]) All() func(yield func(T, error) bool) {
+	return streamIterator(stream.Recv, stream.Close)
+}
+
+// Channel is like ChatCompletionStream.Channel, accumulating the chunks.
+func (stream *AccumulatingChatCompletionStream) Channel(
+	ctx context.Context,
+) (<-chan ChatCompletionStreamResponse, <-chan error) {
+	return streamChannel(ctx, stream.Recv, stream.Close)
+}
+
+// All is like ChatCompletionStream.All, accumulating the chunks.
+func (stream *AccumulatingChatCompletionStream) All() func(yield func(ChatCompletionStreamResponse, error) bool) {
+	return streamIterator(stream.Recv, stream.Close)
+}
+
+func streamChannel[T any](ctx context.Context, recv func() (T, error), closeStream func()) (<-chan T, <-chan error) {
+	values := make(chan T)
+	errs := make(chan error, 1)
+
+	done := make(chan struct{})
+	go func() {
+		// closing the stream unblocks Recv when the context is done
+		select {
+		case <-ctx.Done():
+			closeStream()
+		case <-done:
+		}
+	}()
+
+	go func() {
+		defer close(errs)
+		defer close(values)
+		defer closeStream()
+		defer close(done)
+
+		for {
+			value, err := recv()
+			if ctx.Err() != nil {
+				errs <- ctx.Err()
+				return
+			}
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					errs <- err
+				}
+				return
+			}
+
+			select {
+			case values <- value:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return values, errs
+}
+
+func streamIterator[T any](recv func() (T, error), closeStream func()) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		defer closeStream()
+
+		for {
+			value, err := recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(value, err)
+				return
+			}
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package openai_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+// handleContentChunks streams a chunk for each content, followed by [DONE] unless the stream is
+// kept open, in which case it is kept open until the request is canceled.
+func handleContentChunks(t *testing.T, keepOpen bool, contents ...string) func(http.ResponseWriter, *http.Request) {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, content := range contents {
+			_, err := fmt.Fprintf(w, `data: {"id":"%d","choices":[{"index":0,"delta":{"content":%q}}]}`+"\n\n",
+				i, content)
+			checks.NoError(t, err, "Write error")
+		}
+		if keepOpen {
+			w.(http.Flusher).Flush() //nolint:errcheck // the test server supports flushing
+			<-r.Context().Done()
+			return
+		}
+		_, err := w.Write([]byte("data: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	}
+}
+
+func createContentStream(t *testing.T, client *openai.Client) *openai.ChatCompletionStream {
+	t.Helper()
+	stream, err := client.CreateChatCompletionStream(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	})
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	return stream
+}
+
+func TestStreamChannel(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleContentChunks(t, false, "Hello", " world"))
+
+	chunks, errs := createContentStream(t, client).Channel(context.Background())
+	var content string
+	for chunk := range chunks {
+		content += chunk.Choices[0].Delta.Content
+	}
+	checks.NoError(t, <-errs, "stream error")
+	if content != "Hello world" {
+		t.Fatalf("content = %q, want %q", content, "Hello world")
+	}
+}
+
+func TestStreamChannelCanceled(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleContentChunks(t, true, "Hello"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// the context of the request isn't canceled, closing the stream must unblock Recv
+	chunks, errs := createContentStream(t, client).Channel(ctx)
+	chunk := <-chunks
+	if chunk.Choices[0].Delta.Content != "Hello" {
+		t.Fatalf("unexpected chunk: %+v", chunk)
+	}
+	cancel()
+
+	select {
+	case err := <-errs:
+		checks.ErrorIs(t, err, context.Canceled, "Channel should return the error of the context")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Channel didn't stop when the context was canceled")
+	}
+	if _, ok := <-chunks; ok {
+		t.Fatal("the channel of the chunks isn't closed")
+	}
+}
+
+func TestStreamAll(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleContentChunks(t, false, "Hello", " world", "!"))
+
+	var content string
+	createContentStream(t, client).All()(
+		func(chunk openai.ChatCompletionStreamResponse, err error) bool {
+			checks.NoError(t, err, "stream error")
+			content += chunk.Choices[0].Delta.Content
+			return true
+		})
+	if content != "Hello world!" {
+		t.Fatalf("content = %q, want %q", content, "Hello world!")
+	}
+
+	// the loop can be exited early
+	stream := openai.NewAccumulatingChatCompletionStream(createContentStream(t, client))
+	stream.All()(func(openai.ChatCompletionStreamResponse, error) bool {
+		return false
+	})
+	if content := stream.Response().Choices[0].Message.Content; content != "Hello" {
+		t.Fatalf("accumulated content = %q, want %q", content, "Hello")
+	}
+}
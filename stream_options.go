@@ -0,0 +1,141 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStreamTimeout is returned by streams which didn't receive data within their first chunk or
+// idle timeout. The errors also match ErrTimeout.
+var ErrStreamTimeout = errors.New("stream timed out")
+
+type streamTimeoutError struct {
+	message string
+}
+
+func (e *streamTimeoutError) Error() string {
+	return ErrStreamTimeout.Error() + ": " + e.message
+}
+
+func (e *streamTimeoutError) Is(target error) bool {
+	return target == ErrStreamTimeout || target == ErrTimeout
+}
+
+type streamSettings struct {
+	firstChunkTimeout time.Duration
+	idleTimeout       time.Duration
+}
+
+// StreamOption configures a stream.
+type StreamOption func(*streamSettings)
+
+// WithStreamFirstChunkTimeout limits the time between sending the request and receiving the first
+// chunk of the stream, including the time to receive the headers of the response.
+func WithStreamFirstChunkTimeout(timeout time.Duration) StreamOption {
+	return func(args *streamSettings) {
+		args.firstChunkTimeout = timeout
+	}
+}
+
+// WithStreamIdleTimeout limits the time the stream waits for data, from the server, after the
+// first chunk or, without first chunk timeout, after the headers of the response. Comments sent
+// by the server to keep the connection alive count as data.
+func WithStreamIdleTimeout(timeout time.Duration) StreamOption {
+	return func(args *streamSettings) {
+		args.idleTimeout = timeout
+	}
+}
+
+// streamTimer cancels the request of a stream when it doesn't receive data in time.
+type streamTimer struct {
+	options streamSettings
+	cancel  func()
+
+	mutex      sync.Mutex
+	timer      *time.Timer
+	firstChunk bool
+	stopped    bool
+	err        error
+}
+
+func newStreamTimer(options streamSettings, cancel func()) *streamTimer {
+	t := &streamTimer{options: options, cancel: cancel}
+	if options.firstChunkTimeout > 0 {
+		t.timer = time.AfterFunc(options.firstChunkTimeout, func() {
+			t.timeout(&streamTimeoutError{fmt.Sprintf("no chunk received within %s", options.firstChunkTimeout)})
+		})
+	}
+	return t
+}
+
+func (t *streamTimer) timeout(err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.err == nil {
+		t.err = err
+	}
+	t.cancel()
+}
+
+// received restarts the idle timeout when the stream receives data.
+func (t *streamTimer) received() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.stopped || t.err != nil || t.options.idleTimeout <= 0 || (!t.firstChunk && t.options.firstChunkTimeout > 0) {
+		return
+	}
+
+	if t.timer == nil {
+		idleTimeout := t.options.idleTimeout
+		t.timer = time.AfterFunc(idleTimeout, func() {
+			t.timeout(&streamTimeoutError{fmt.Sprintf("no data received within %s", idleTimeout)})
+		})
+		return
+	}
+	t.timer.Reset(t.options.idleTimeout)
+}
+
+// receivedFirstChunk replaces the first chunk timeout by the idle timeout.
+func (t *streamTimer) receivedFirstChunk() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	if t.timer != nil && t.options.firstChunkTimeout > 0 {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.firstChunk = true
+	t.mutex.Unlock()
+
+	t.received()
+}
+
+// stop stops the timer and returns the timeout error, if the stream timed out.
+func (t *streamTimer) stop() error {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	return t.err
+}
+
+// timeoutErr returns the timeout error, if the stream timed out.
+func (t *streamTimer) timeoutErr() error {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.err
+}
@@ -0,0 +1,76 @@
+package openai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+)
+
+// handleStalledStream sends the chunks with a delay and then stalls until the request is canceled.
+func handleStalledStream(delay time.Duration, chunks ...string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush() //nolint:errcheck // the test server supports flushing
+		for _, chunk := range chunks {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+			w.Write([]byte(chunk))   //nolint:errcheck // the client may be gone
+			w.(http.Flusher).Flush() //nolint:errcheck // the test server supports flushing
+		}
+		<-r.Context().Done()
+	}
+}
+
+func TestChatCompletionStreamTimeouts(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	chunk := `data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n"
+	server.RegisterHandler("/v1/chat/completions", handleStalledStream(50*time.Millisecond,
+		": keep-alive\n\n", chunk, ": keep-alive\n\n"))
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT3Dot5Turbo,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Hello!"}},
+	}
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), request,
+		openai.WithStreamFirstChunkTimeout(20*time.Millisecond))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, openai.ErrStreamTimeout, "Recv should time out before the first chunk")
+	checks.ErrorIs(t, err, openai.ErrTimeout, "stream timeouts should be timeouts")
+	if stream.TimeToFirstToken() != 0 {
+		t.Fatalf("TimeToFirstToken() = %s, want 0 without chunks", stream.TimeToFirstToken())
+	}
+	stream.Close()
+
+	// the keep-alive comments restart the idle timeout, the first chunk timeout isn't restarted
+	stream, err = client.CreateChatCompletionStream(context.Background(), request,
+		openai.WithStreamFirstChunkTimeout(time.Second), openai.WithStreamIdleTimeout(80*time.Millisecond))
+	checks.NoError(t, err, "CreateChatCompletionStream error")
+	defer stream.Close()
+	_, err = stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	if ttft := stream.TimeToFirstToken(); ttft < 100*time.Millisecond || ttft > time.Second {
+		t.Fatalf("TimeToFirstToken() = %s, want the time until the second message", ttft)
+	}
+
+	start := time.Now()
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, openai.ErrStreamTimeout, "Recv should time out when the stream stalls")
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("Recv() timed out after %s, the keep-alive comment didn't restart the idle timeout", elapsed)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+		t.Fatalf("Recv() error = %v, want only ErrStreamTimeout", err)
+	}
+}
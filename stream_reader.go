@@ -3,98 +3,295 @@ package openai
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	utils "github.com/sashabaranov/go-openai/internal"
 )
 
 var (
-	headerData  = []byte("data: ")
-	errorPrefix = []byte(`data: {"error":`)
+	errorPrefix = []byte(`{"error":`)
+	doneData    = []byte("[DONE]")
 )
 
-type streamable interface {
-	ChatCompletionStreamResponse | CompletionResponse
+const (
+	sseFieldData  = "data"
+	sseFieldEvent = "event"
+	sseFieldID    = "id"
+	sseFieldRetry = "retry"
+
+	sseEventError = "error"
+)
+
+// StreamEvent is an event of a Server-Sent Events stream.
+type StreamEvent struct {
+	// Event is the name of the event, it's empty for unnamed events.
+	Event string
+	// ID is the last event ID of the stream when the event was dispatched.
+	ID string
+	// Data is the data of the event, multiple data lines are joined by newlines.
+	Data []byte
 }
 
-type streamReader[T streamable] struct {
+// StreamDecoder decodes the data of an event into a value of a stream. It returns
+// ErrStreamEventSkipped for events which don't carry a value, e.g. status updates.
+type StreamDecoder[T any] func(event StreamEvent) (T, error)
+
+// Stream is a stream of values of type T decoded from the events of a Server-Sent Events
+// response. By default the data of the events is decoded as JSON, regardless of their name.
+// Error events are returned as errors and the stream ends with a data: [DONE] message.
+// The headers of the response, e.g. the rate limits, are available with Header, GetRateLimitHeaders
+// and Metadata.
+type Stream[T any] struct {
 	emptyMessagesLimit uint
 	isFinished         bool
+	decoder            StreamDecoder[T]
 
 	reader         *bufio.Reader
 	response       *http.Response
 	errAccumulator utils.ErrorAccumulator
 	unmarshaler    utils.Unmarshaler
 
+	// lastEventID and retry are the last values of the id and retry fields of the stream.
+	lastEventID string
+	retry       time.Duration
+
+	timer  *streamTimer
+	cancel context.CancelFunc
+	// start is the time the request was sent, timeToFirstChunk is zero until the first chunk is received.
+	start            time.Time
+	timeToFirstChunk time.Duration
+
 	httpHeader
 }
 
-func (stream *streamReader[T]) Recv() (response T, err error) {
+func (stream *Stream[T]) Recv() (response T, err error) {
 	if stream.isFinished {
 		err = io.EOF
 		return
 	}
 
 	response, err = stream.processLines()
+	if err != nil {
+		if timeoutErr := stream.timer.timeoutErr(); timeoutErr != nil {
+			err = timeoutErr
+		}
+		return
+	}
+
+	if stream.timeToFirstChunk == 0 {
+		stream.timeToFirstChunk = time.Since(stream.start)
+		stream.timer.receivedFirstChunk()
+	}
 	return
 }
 
+func (stream *Stream[T]) processLines() (T, error) {
+	for {
+		event, err := stream.readEvent()
+		if err != nil {
+			return *new(T), err
+		}
+
+		response, err := stream.decodeEvent(event)
+		if errors.Is(err, ErrStreamEventSkipped) {
+			continue
+		}
+		return response, err
+	}
+}
+
+// decodeError returns the error of the event, or nil when its data isn't an error response.
+// Error events always return an error, their data may be the error object without the
+// {"error": ...} wrapper, or not even an error object.
+func (stream *Stream[T]) decodeError(event StreamEvent) *APIError {
+	var errResp ErrorResponse
+	if err := stream.unmarshaler.Unmarshal(event.Data, &errResp); err == nil && errResp.Error != nil {
+		return errResp.Error
+	}
+	if event.Event != sseEventError {
+		return nil
+	}
+
+	apiErr := &APIError{}
+	if err := stream.unmarshaler.Unmarshal(event.Data, apiErr); err != nil {
+		apiErr = &APIError{Message: string(event.Data)}
+	}
+	return apiErr
+}
+
+func (stream *Stream[T]) decodeEvent(event StreamEvent) (T, error) {
+	if event.Event == sseEventError || bytes.HasPrefix(event.Data, errorPrefix) {
+		if apiErr := stream.decodeError(event); apiErr != nil {
+			apiErr.Metadata = stream.Metadata()
+			return *new(T), fmt.Errorf("error, %w", apiErr)
+		}
+	}
+
+	if bytes.Equal(event.Data, doneData) {
+		stream.isFinished = true
+		stream.timer.stop()
+		return *new(T), io.EOF
+	}
+
+	if stream.decoder != nil {
+		return stream.decoder(event)
+	}
+
+	var response T
+	unmarshalErr := stream.unmarshaler.Unmarshal(event.Data, &response)
+	if unmarshalErr != nil {
+		return *new(T), unmarshalErr
+	}
+
+	return response, nil
+}
+
+// readEvent reads the next event with data of the stream, following the Server-Sent Events
+// specification. Fields with unknown names are ignored. Lines which aren't fields, e.g. the lines
+// of an error response which isn't encoded as an event, are written to the error accumulator and
+// count as empty messages.
+//
 //nolint:gocognit
-func (stream *streamReader[T]) processLines() (T, error) {
+func (stream *Stream[T]) readEvent() (StreamEvent, error) {
 	var (
 		emptyMessagesCount uint
-		hasErrorPrefix     bool
+		event              StreamEvent
+		data               sseData
+		pending            bool
 	)
 
 	for {
 		rawLine, readErr := stream.reader.ReadBytes('\n')
-		if readErr != nil || hasErrorPrefix {
+		if len(rawLine) > 0 {
+			stream.timer.received()
+		}
+		if readErr != nil {
+			// the last event may not be terminated by an empty line
+			if len(bytes.TrimSpace(rawLine)) > 0 {
+				pending = stream.processField(rawLine, &event, &data) || pending
+			}
+			if data.received {
+				return stream.dispatchEvent(event, &data), nil
+			}
+
 			respErr := stream.unmarshalError()
 			if respErr != nil {
-				return *new(T), fmt.Errorf("error, %w", respErr.Error)
+				if respErr.Error != nil {
+					respErr.Error.Metadata = stream.Metadata()
+				}
+				return StreamEvent{}, fmt.Errorf("error, %w", respErr.Error)
 			}
-			return *new(T), readErr
+			return StreamEvent{}, readErr
 		}
 
-		noSpaceLine := bytes.TrimSpace(rawLine)
-		if bytes.HasPrefix(noSpaceLine, errorPrefix) {
-			hasErrorPrefix = true
+		line := trimLineEnding(rawLine)
+		if len(line) > 0 && line[0] == ':' {
+			// comment, e.g. a keep-alive message
+			continue
 		}
-		if !bytes.HasPrefix(noSpaceLine, headerData) || hasErrorPrefix {
-			if hasErrorPrefix {
-				noSpaceLine = bytes.TrimPrefix(noSpaceLine, headerData)
-			}
-			writeErr := stream.errAccumulator.Write(noSpaceLine)
-			if writeErr != nil {
-				return *new(T), writeErr
+
+		if len(line) > 0 {
+			if stream.processField(line, &event, &data) {
+				pending = true
+				continue
 			}
-			emptyMessagesCount++
-			if emptyMessagesCount > stream.emptyMessagesLimit {
-				return *new(T), ErrTooManyEmptyStreamMessages
+		} else if pending {
+			// an empty line dispatches the event, events without data fields are ignored
+			if data.received {
+				return stream.dispatchEvent(event, &data), nil
 			}
-
+			event, pending = StreamEvent{}, false
 			continue
 		}
 
-		noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
-		if string(noPrefixLine) == "[DONE]" {
-			stream.isFinished = true
-			return *new(T), io.EOF
+		writeErr := stream.errAccumulator.Write(bytes.TrimSpace(line))
+		if writeErr != nil {
+			return StreamEvent{}, writeErr
 		}
+		emptyMessagesCount++
+		if emptyMessagesCount > stream.emptyMessagesLimit {
+			return StreamEvent{}, ErrTooManyEmptyStreamMessages
+		}
+	}
+}
+
+// sseData is the data of the event being read. Received reports whether the event has data
+// fields, as the data of an event may be empty.
+type sseData struct {
+	buffer   bytes.Buffer
+	received bool
+}
 
-		var response T
-		unmarshalErr := stream.unmarshaler.Unmarshal(noPrefixLine, &response)
-		if unmarshalErr != nil {
-			return *new(T), unmarshalErr
+// processField processes a field line of an event, it reports whether the line is a field. The
+// fields with unknown names are ignored.
+func (stream *Stream[T]) processField(line []byte, event *StreamEvent, data *sseData) bool {
+	line = trimLineEnding(line)
+	field, value, found := bytes.Cut(line, []byte(":"))
+	if found && len(value) > 0 && value[0] == ' ' {
+		value = value[1:]
+	}
+
+	switch string(field) {
+	case sseFieldData:
+		if data.received {
+			data.buffer.WriteByte('\n')
+		}
+		data.buffer.Write(value)
+		data.received = true
+	case sseFieldEvent:
+		event.Event = string(value)
+	case sseFieldID:
+		// IDs with NULL characters are ignored
+		if bytes.IndexByte(value, 0) < 0 {
+			stream.lastEventID = string(value)
 		}
+	case sseFieldRetry:
+		milliseconds, err := strconv.ParseUint(string(value), 10, 63)
+		if err == nil {
+			stream.retry = time.Duration(milliseconds) * time.Millisecond
+		}
+	default:
+		return isSSEFieldName(field)
+	}
+	return true
+}
 
-		return response, nil
+// isSSEFieldName reports whether the name looks like the name of a field rather than a line of
+// a response which isn't a stream, e.g. a JSON error body.
+func isSSEFieldName(name []byte) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for _, c := range name {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		if !isLetter && !(c >= '0' && c <= '9') && c != '_' && c != '-' {
+			return false
+		}
 	}
+	return true
+}
+
+func (stream *Stream[T]) dispatchEvent(event StreamEvent, data *sseData) StreamEvent {
+	event.ID = stream.lastEventID
+	event.Data = append([]byte{}, data.buffer.Bytes()...)
+	data.buffer.Reset()
+	data.received = false
+	return event
+}
+
+// trimLineEnding removes the LF or CRLF ending of a line.
+func trimLineEnding(line []byte) []byte {
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r"))
 }
 
-func (stream *streamReader[T]) unmarshalError() (errResp *ErrorResponse) {
+func (stream *Stream[T]) unmarshalError() (errResp *ErrorResponse) {
 	errBytes := stream.errAccumulator.Bytes()
 	if len(errBytes) == 0 {
 		return
@@ -108,6 +305,22 @@ func (stream *streamReader[T]) unmarshalError() (errResp *ErrorResponse) {
 	return
 }
 
-func (stream *streamReader[T]) Close() {
+// LastEventID returns the last event ID sent by the server, which identifies the position of
+// the stream.
+func (stream *Stream[T]) LastEventID() string {
+	return stream.lastEventID
+}
+
+// TimeToFirstToken returns the time between sending the request and receiving the first chunk of
+// the stream. It is zero until the first chunk is received.
+func (stream *Stream[T]) TimeToFirstToken() time.Duration {
+	return stream.timeToFirstChunk
+}
+
+func (stream *Stream[T]) Close() {
+	stream.timer.stop()
+	if stream.cancel != nil {
+		stream.cancel()
+	}
 	stream.response.Body.Close()
 }
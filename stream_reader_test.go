@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"io"
 	"testing"
+	"time"
 
 	utils "github.com/sashabaranov/go-openai/internal"
 	"github.com/sashabaranov/go-openai/internal/test"
@@ -20,7 +22,7 @@ func (*failingUnMarshaller) Unmarshal(_ []byte, _ any) error {
 }
 
 func TestStreamReaderReturnsUnmarshalerErrors(t *testing.T) {
-	stream := &streamReader[ChatCompletionStreamResponse]{
+	stream := &Stream[ChatCompletionStreamResponse]{
 		errAccumulator: utils.NewErrorAccumulator(),
 		unmarshaler:    &failingUnMarshaller{},
 	}
@@ -42,7 +44,7 @@ func TestStreamReaderReturnsUnmarshalerErrors(t *testing.T) {
 }
 
 func TestStreamReaderReturnsErrTooManyEmptyStreamMessages(t *testing.T) {
-	stream := &streamReader[ChatCompletionStreamResponse]{
+	stream := &Stream[ChatCompletionStreamResponse]{
 		emptyMessagesLimit: 3,
 		reader:             bufio.NewReader(bytes.NewReader([]byte("\n\n\n\n"))),
 		errAccumulator:     utils.NewErrorAccumulator(),
@@ -53,7 +55,7 @@ func TestStreamReaderReturnsErrTooManyEmptyStreamMessages(t *testing.T) {
 }
 
 func TestStreamReaderReturnsErrTestErrorAccumulatorWriteFailed(t *testing.T) {
-	stream := &streamReader[ChatCompletionStreamResponse]{
+	stream := &Stream[ChatCompletionStreamResponse]{
 		reader: bufio.NewReader(bytes.NewReader([]byte("\n"))),
 		errAccumulator: &utils.DefaultErrorAccumulator{
 			Buffer: &test.FailingErrorBuffer{},
@@ -63,3 +65,117 @@ func TestStreamReaderReturnsErrTestErrorAccumulatorWriteFailed(t *testing.T) {
 	_, err := stream.Recv()
 	checks.ErrorIs(t, err, test.ErrTestErrorAccumulatorWriteFailed, "Did not return error when write failed", err.Error())
 }
+
+func newTestStreamReader(data string) *Stream[ChatCompletionStreamResponse] {
+	return &Stream[ChatCompletionStreamResponse]{
+		emptyMessagesLimit: 3,
+		reader:             bufio.NewReader(bytes.NewReader([]byte(data))),
+		errAccumulator:     utils.NewErrorAccumulator(),
+		unmarshaler:        &utils.JSONUnmarshaler{},
+	}
+}
+
+func TestStreamReaderParsesEvents(t *testing.T) {
+	stream := newTestStreamReader(": keep-alive\r\n" +
+		"retry: 1500\r\n" +
+		"id: 1\r\n" +
+		"event: chunk\r\n" +
+		"data: {\"id\":\r\n" +
+		"data:\"chatcmpl-1\"}\r\n" +
+		"\r\n" +
+		"event: ping\n" +
+		"\n" +
+		"data\n" +
+		"data: x\n" +
+		"\n" +
+		"data:\n" +
+		"\n" +
+		"id: 2\n" +
+		"data: {\"id\":\"chatcmpl-2\"}\n" +
+		"\n" +
+		"data: [DONE]\n")
+
+	event, err := stream.readEvent()
+	checks.NoError(t, err, "readEvent error")
+	if event.Event != "chunk" || event.ID != "1" || string(event.Data) != "{\"id\":\n\"chatcmpl-1\"}" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	if stream.retry != 1500*time.Millisecond {
+		t.Fatalf("retry = %s, want 1.5s", stream.retry)
+	}
+
+	// the event without data is skipped, empty data fields are kept
+	event, err = stream.readEvent()
+	checks.NoError(t, err, "readEvent error")
+	if event.Event != "" || string(event.Data) != "\nx" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+	event, err = stream.readEvent()
+	checks.NoError(t, err, "readEvent error")
+	if event.Data == nil || len(event.Data) != 0 {
+		t.Fatalf("unexpected event: %+v, want an event with empty data", event)
+	}
+
+	response, err := stream.Recv()
+	checks.NoError(t, err, "Recv error")
+	if response.ID != "chatcmpl-2" || stream.lastEventID != "2" {
+		t.Fatalf("unexpected response %+v with last event ID %s", response, stream.lastEventID)
+	}
+
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "Recv should return io.EOF after [DONE]")
+	if !stream.isFinished {
+		t.Fatalf("stream isn't finished after [DONE]")
+	}
+}
+
+func TestStreamReaderReturnsErrorEvents(t *testing.T) {
+	stream := newTestStreamReader("event: error\n" +
+		"data: {\"error\": {\"message\": \"The server is overloaded\",\n" +
+		"data: \"type\": \"server_error\"}}\n" +
+		"\n")
+	_, err := stream.Recv()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "The server is overloaded" {
+		t.Fatalf("Recv() error = %v, want the APIError of the error event", err)
+	}
+}
+
+func TestStreamReaderReturnsUnwrappedErrorEvents(t *testing.T) {
+	stream := newTestStreamReader("event: error\n" +
+		"data: {\"code\":\"server_error\",\"message\":\"boom\"}\n" +
+		"\n")
+	_, err := stream.Recv()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "boom" || apiErr.Code != "server_error" {
+		t.Fatalf("Recv() error = %v, want the APIError of the error event", err)
+	}
+
+	// error events return an error whatever their data
+	stream = newTestStreamReader("event: error\ndata: overloaded\n\n")
+	_, err = stream.Recv()
+	if !errors.As(err, &apiErr) || apiErr.Message != "overloaded" {
+		t.Fatalf("Recv() error = %v, want an APIError with the data of the error event", err)
+	}
+}
+
+func TestStreamReaderAccumulatesUnknownLines(t *testing.T) {
+	stream := newTestStreamReader("{\n\"error\": {\"message\": \"Incorrect API key provided\"}\n}\n")
+	_, err := stream.Recv()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Incorrect API key provided" {
+		t.Fatalf("Recv() error = %v, want the APIError of the response", err)
+	}
+
+}
+
+func TestStreamReaderIgnoresUnknownFields(t *testing.T) {
+	stream := newTestStreamReader("event: thread.run.created\nfoo: bar\nbaz\n\n" +
+		"foo: bar\ndata: {\"id\":\"chatcmpl-1\"}\nbaz\n\n")
+	stream.emptyMessagesLimit = 0
+	response, err := stream.Recv()
+	checks.NoError(t, err, "unknown fields should be ignored")
+	if response.ID != "chatcmpl-1" || len(stream.errAccumulator.Bytes()) != 0 {
+		t.Fatalf("unexpected response %+v, accumulated %q", response, stream.errAccumulator.Bytes())
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -193,7 +194,7 @@ func TestCreateCompletionStreamTooManyEmptyStreamMessagesError(t *testing.T) {
 		dataBytes = append(dataBytes, []byte("data: "+data+"\n\n")...)
 
 		// Totally 301 empty messages (300 is the limit)
-		for i := 0; i < 299; i++ {
+		for i := 0; i < 301; i++ {
 			dataBytes = append(dataBytes, '\n')
 		}
 
@@ -326,6 +327,68 @@ func TestCreateCompletionStreamReturnTimeoutError(t *testing.T) {
 	}
 }
 
+type runStreamEvent struct {
+	Name   string
+	ID     string
+	Status string `json:"status"`
+	Delta  string `json:"delta"`
+}
+
+func TestCreateStream(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/threads/runs", func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]any
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		if request["stream"] != true {
+			t.Errorf("the request doesn't enable streaming: %v", request)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err = w.Write([]byte("event: thread.run.created\nid: 1\ndata: {\"status\": \"queued\"}\n\n" +
+			"event: thread.run.step.created\ndata: {}\n\n" +
+			"event: thread.message.delta\nid: 2\ndata: {\"delta\": \"Hello\"}\n\n" +
+			"event: done\ndata: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	decoder := func(event openai.StreamEvent) (runStreamEvent, error) {
+		if event.Event == "thread.run.step.created" {
+			return runStreamEvent{}, openai.ErrStreamEventSkipped
+		}
+		value := runStreamEvent{Name: event.Event, ID: event.ID}
+		err := json.Unmarshal(event.Data, &value)
+		return value, err
+	}
+	stream, err := openai.CreateStream(context.Background(), client, "/threads/runs", openai.GPT4,
+		map[string]any{"assistant_id": "asst_1", "stream": true}, decoder)
+	checks.NoError(t, err, "CreateStream error")
+	defer stream.Close()
+
+	var events []runStreamEvent
+	for {
+		var event runStreamEvent
+		event, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		checks.NoError(t, err, "Recv error")
+		events = append(events, event)
+	}
+
+	want := []runStreamEvent{
+		{Name: "thread.run.created", ID: "1", Status: "queued"},
+		{Name: "thread.message.delta", ID: "2", Delta: "Hello"},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if stream.LastEventID() != "2" {
+		t.Fatalf("LastEventID() = %s, want 2", stream.LastEventID())
+	}
+}
+
 // Helper funcs.
 func compareResponses(r1, r2 openai.CompletionResponse) bool {
 	if r1.ID != r2.ID || r1.Object != r2.Object || r1.Created != r2.Created || r1.Model != r2.Model {
@@ -0,0 +1,141 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const (
+	defaultStructuredOutputMaxRetries = 2
+
+	defaultStructuredOutputSchemaPrompt = "Respond with a JSON object which matches the following JSON schema:\n"
+	structuredOutputRetryPrompt         = "The response is invalid: %s\nRespond again with a JSON object " +
+		"which matches the JSON schema."
+)
+
+var (
+	ErrStructuredOutputNoChoices = errors.New("structured output received a completion without choices")
+	ErrStructuredOutputInvalid   = errors.New("structured output doesn't match the schema")
+)
+
+// StructuredOutput creates chat completions in JSON mode and decodes them into Go values.
+type StructuredOutput struct {
+	// Schema is the JSON schema of the response sent to the model. By default it is generated from
+	// the type of the value with jsonschema.GenerateSchemaForType.
+	Schema *jsonschema.Definition
+	// SchemaPrompt precedes the JSON encoded schema in the system message sent to the model.
+	SchemaPrompt string
+	// MaxRetries limits the number of completions retried after an invalid response, defaults to 2.
+	// A negative value disables the retries.
+	MaxRetries int
+}
+
+// StructuredOutputResult is the result of CreateStructuredOutput.
+type StructuredOutputResult[T any] struct {
+	// Value is the decoded content of the last completion.
+	Value T
+	// Response is the last chat completion.
+	Response ChatCompletionResponse
+	// Usage is the sum of the usage of all the chat completions.
+	Usage Usage
+	// Attempts is the number of chat completions.
+	Attempts int
+}
+
+// CreateStructuredOutput creates a chat completion in JSON mode and decodes the content of its first
+// choice into a value of type T. The schema of T is sent to the model in a system message preceding
+// the messages of the request, and the content is validated against it before decoding. When the
+// content is invalid, the completion is retried with the response of the model and the validation
+// error appended to the conversation.
+//
+// JSON mode only generates JSON objects, so T should be encoded as an object, e.g. a struct or a map.
+func CreateStructuredOutput[T any](
+	ctx context.Context,
+	client *Client,
+	request ChatCompletionRequest,
+	options StructuredOutput,
+) (result StructuredOutputResult[T], err error) {
+	schema := options.Schema
+	if schema == nil {
+		schema, err = jsonschema.GenerateSchemaForType(result.Value)
+		if err != nil {
+			err = fmt.Errorf("failed to generate the schema of the structured output: %w", err)
+			return
+		}
+	}
+
+	systemMessage, err := options.systemMessage(*schema)
+	if err != nil {
+		return
+	}
+	request.ResponseFormat = &ChatCompletionResponseFormat{Type: ChatCompletionResponseFormatTypeJSONObject}
+	request.Messages = append([]ChatCompletionMessage{systemMessage}, request.Messages...)
+
+	maxRetries := options.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultStructuredOutputMaxRetries
+	}
+
+	for {
+		result.Response, err = client.CreateChatCompletion(ctx, request)
+		if err != nil {
+			return
+		}
+		result.Attempts++
+		result.Usage.PromptTokens += result.Response.Usage.PromptTokens
+		result.Usage.CompletionTokens += result.Response.Usage.CompletionTokens
+		result.Usage.TotalTokens += result.Response.Usage.TotalTokens
+
+		if len(result.Response.Choices) == 0 {
+			err = ErrStructuredOutputNoChoices
+			return
+		}
+
+		message := result.Response.Choices[0].Message
+		var value T
+		validationErr := decodeStructuredOutput(*schema, message.Content, &value)
+		if validationErr == nil {
+			result.Value = value
+			return
+		}
+		if result.Attempts > maxRetries {
+			err = fmt.Errorf("%w: %v", ErrStructuredOutputInvalid, validationErr)
+			return
+		}
+
+		request.Messages = append(request.Messages, ChatCompletionMessage{
+			Role:    ChatMessageRoleAssistant,
+			Content: message.Content,
+		}, ChatCompletionMessage{
+			Role:    ChatMessageRoleUser,
+			Content: fmt.Sprintf(structuredOutputRetryPrompt, validationErr),
+		})
+	}
+}
+
+func (o StructuredOutput) systemMessage(schema jsonschema.Definition) (ChatCompletionMessage, error) {
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return ChatCompletionMessage{}, fmt.Errorf("failed to encode the schema of the structured output: %w", err)
+	}
+
+	prompt := o.SchemaPrompt
+	if prompt == "" {
+		prompt = defaultStructuredOutputSchemaPrompt
+	}
+	return ChatCompletionMessage{
+		Role:    ChatMessageRoleSystem,
+		Content: prompt + string(b),
+	}, nil
+}
+
+func decodeStructuredOutput(schema jsonschema.Definition, content string, value any) error {
+	if err := jsonschema.ValidateJSON(schema, []byte(content)); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(content), value)
+}
@@ -0,0 +1,148 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type extractedPerson struct {
+	Name string `json:"name" description:"The full name of the person"`
+	Age  int    `json:"age"`
+}
+
+// handleStructuredOutputEndpoint answers the chat completions with the contents in order.
+func handleStructuredOutputEndpoint(
+	t *testing.T,
+	requests *[]openai.ChatCompletionRequest,
+	contents ...string,
+) func(http.ResponseWriter, *http.Request) {
+	t.Helper()
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatCompletionRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		*requests = append(*requests, request)
+
+		content := contents[0]
+		if len(contents) > 1 {
+			contents = contents[1:]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+				FinishReason: openai.FinishReasonStop,
+			}},
+			Usage: openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		})
+	}
+}
+
+func TestCreateStructuredOutput(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var requests []openai.ChatCompletionRequest
+	server.RegisterHandler("/v1/chat/completions", handleStructuredOutputEndpoint(t, &requests,
+		`{"name": "John Doe"}`, `{"name": "John Doe", "age": "42"}`, `{"name": "John Doe", "age": 42}`))
+
+	result, err := openai.CreateStructuredOutput[extractedPerson](context.Background(), client,
+		openai.ChatCompletionRequest{
+			Model:    openai.GPT4TurboPreview,
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "John Doe is 42."}},
+		}, openai.StructuredOutput{})
+	checks.NoError(t, err, "CreateStructuredOutput error")
+
+	if result.Value != (extractedPerson{Name: "John Doe", Age: 42}) {
+		t.Fatalf("unexpected value: %+v", result.Value)
+	}
+	if result.Attempts != 3 || len(requests) != 3 || result.Usage.TotalTokens != 45 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	first := requests[0]
+	if first.ResponseFormat == nil || first.ResponseFormat.Type != openai.ChatCompletionResponseFormatTypeJSONObject {
+		t.Fatalf("JSON mode wasn't enabled: %+v", first.ResponseFormat)
+	}
+	if roles := messageRoles(first.Messages); roles != "system,user" {
+		t.Fatalf("unexpected messages: %s", roles)
+	}
+	if !strings.Contains(first.Messages[0].Content, `"description":"The full name of the person"`) {
+		t.Fatalf("the schema wasn't derived from the type: %s", first.Messages[0].Content)
+	}
+
+	last := requests[2]
+	if roles := messageRoles(last.Messages); roles != "system,user,assistant,user,assistant,user" {
+		t.Fatalf("unexpected messages: %s", roles)
+	}
+	if content := last.Messages[3].Content; !strings.Contains(content, "$.age: is required") {
+		t.Fatalf("the validation error wasn't sent: %s", content)
+	}
+	if content := last.Messages[5].Content; !strings.Contains(content, "$.age: expected integer, got string") {
+		t.Fatalf("the validation error wasn't sent: %s", content)
+	}
+}
+
+func TestCreateStructuredOutputErrors(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var requests []openai.ChatCompletionRequest
+	server.RegisterHandler("/v1/chat/completions", handleStructuredOutputEndpoint(t, &requests, `{"name": 1}`))
+
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4TurboPreview,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Who is John Doe?"}},
+	}
+	result, err := openai.CreateStructuredOutput[extractedPerson](context.Background(), client, request,
+		openai.StructuredOutput{MaxRetries: 1})
+	checks.ErrorIs(t, err, openai.ErrStructuredOutputInvalid, "CreateStructuredOutput should fail after the retries")
+	if result.Attempts != 2 {
+		t.Fatalf("CreateStructuredOutput() attempts = %d, want 2", result.Attempts)
+	}
+
+	// the schema of the options is sent instead of the generated one
+	requests = nil
+	schema := &jsonschema.Definition{
+		Type:       jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{"name": {Type: jsonschema.Integer}},
+	}
+	value, err := openai.CreateStructuredOutput[map[string]int](context.Background(), client, request,
+		openai.StructuredOutput{Schema: schema, SchemaPrompt: "Schema: ", MaxRetries: -1})
+	checks.NoError(t, err, "CreateStructuredOutput error")
+	if value.Value["name"] != 1 || len(requests) != 1 {
+		t.Fatalf("unexpected result: %+v", value)
+	}
+	if content := requests[0].Messages[0].Content; !strings.HasPrefix(content, `Schema: {"type":"object"`) {
+		t.Fatalf("the schema of the options wasn't sent: %s", content)
+	}
+}
+
+type extractedTemperature struct {
+	Value float64 `json:"value"`
+	Unit  *string `json:"unit" enum:"celsius,fahrenheit"`
+}
+
+func TestCreateStructuredOutputNullableEnum(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var requests []openai.ChatCompletionRequest
+	server.RegisterHandler("/v1/chat/completions", handleStructuredOutputEndpoint(t, &requests,
+		`{"value": 21, "unit": null}`))
+
+	// null is a valid value of the optional enum field, the reply isn't retried
+	result, err := openai.CreateStructuredOutput[extractedTemperature](context.Background(), client,
+		openai.ChatCompletionRequest{
+			Model:    openai.GPT4TurboPreview,
+			Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "It's 21 degrees."}},
+		}, openai.StructuredOutput{MaxRetries: 1})
+	checks.NoError(t, err, "CreateStructuredOutput error")
+	if result.Value.Value != 21 || result.Value.Unit != nil || result.Attempts != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
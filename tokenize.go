@@ -8,11 +8,19 @@ import (
 
 func Tokenize(model string, text string) (ids []uint, tokens []string, err error) {
 	var c tokenizer.Codec
-	c, err = tokenizer.ForModel(tokenizer.Model(model))
+	c, err = codecForModel(model)
 	if err != nil {
-		err = fmt.Errorf("model not supported: %w", err)
 		return
 	}
 
 	return c.Encode(text)
 }
+
+// codecForModel returns the tokenizer of the model, unknown models fall back to cl100k_base.
+func codecForModel(model string) (tokenizer.Codec, error) {
+	c, err := tokenizer.ForModel(tokenizer.Model(model))
+	if err != nil {
+		return nil, fmt.Errorf("model not supported: %w", err)
+	}
+	return c, nil
+}
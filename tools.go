@@ -0,0 +1,254 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+const defaultToolRunnerMaxIterations = 10
+
+var (
+	ErrToolNotFound              = errors.New("tool not found")
+	ErrToolPanicked              = errors.New("tool handler panicked")
+	ErrToolRunnerMaxIterations   = errors.New("tool runner exceeded the maximum number of iterations")
+	ErrToolRunnerNoChoices       = errors.New("tool runner received a completion without choices")
+	ErrToolRunnerMultipleChoices = errors.New("tool runner doesn't support requests with N > 1")
+	ErrToolRunnerFunctionCall    = errors.New("tool runner doesn't support legacy function calls")
+)
+
+// ToolHandler executes a tool call with the JSON encoded arguments generated by the model
+// and returns the content of the tool message sent back to the model.
+type ToolHandler func(ctx context.Context, arguments string) (string, error)
+
+type registeredTool struct {
+	definition FunctionDefinition
+	handler    ToolHandler
+}
+
+// ToolRegistry maps the names of function tools to their handlers.
+type ToolRegistry struct {
+	mutex sync.RWMutex
+	tools map[string]registeredTool
+	names []string
+}
+
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: make(map[string]registeredTool),
+	}
+}
+
+// Register registers the handler of a function tool, replacing any tool with the same name.
+func (r *ToolRegistry) Register(definition FunctionDefinition, handler ToolHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.tools[definition.Name]; !ok {
+		r.names = append(r.names, definition.Name)
+	}
+	r.tools[definition.Name] = registeredTool{definition: definition, handler: handler}
+}
+
+// RegisterTool registers a handler with typed arguments, which are decoded from the JSON arguments
+// generated by the model. When the definition has no parameters, they are generated from the
+// type of the arguments with jsonschema.GenerateSchemaForType. Results which aren't strings are
+// encoded as JSON.
+func RegisterTool[T, R any](
+	r *ToolRegistry,
+	definition FunctionDefinition,
+	handler func(ctx context.Context, arguments T) (R, error),
+) error {
+	if definition.Parameters == nil {
+		var args T
+		parameters, err := jsonschema.GenerateSchemaForType(args)
+		if err != nil {
+			return fmt.Errorf("failed to generate parameters of tool %s: %w", definition.Name, err)
+		}
+		definition.Parameters = parameters
+	}
+
+	r.Register(definition, func(ctx context.Context, arguments string) (string, error) {
+		var args T
+		if arguments != "" {
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+
+		result, err := handler(ctx, args)
+		if err != nil {
+			return "", err
+		}
+
+		if s, ok := any(result).(string); ok {
+			return s, nil
+		}
+		b, err := json.Marshal(result)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode result: %w", err)
+		}
+		return string(b), nil
+	})
+	return nil
+}
+
+// Tools returns the registered tools in the order of their registration.
+func (r *ToolRegistry) Tools() []Tool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tools := make([]Tool, len(r.names))
+	for i, name := range r.names {
+		tools[i] = Tool{Type: ToolTypeFunction, Function: r.tools[name].definition}
+	}
+	return tools
+}
+
+// Call executes the handler of a tool call and returns its result.
+func (r *ToolRegistry) Call(ctx context.Context, call ToolCall) (string, error) {
+	r.mutex.RLock()
+	tool, ok := r.tools[call.Function.Name]
+	r.mutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrToolNotFound, call.Function.Name)
+	}
+
+	return tool.handler(ctx, call.Function.Arguments)
+}
+
+// ToolRunner runs chat completions calling tools until the model answers without calling any tool.
+type ToolRunner struct {
+	Client   *Client
+	Registry *ToolRegistry
+	// MaxIterations limits the number of chat completions of a run, defaults to 10.
+	MaxIterations int
+	// HandleError returns the content of the tool message sent to the model when a tool call fails.
+	// By default the error is reported to the model, returning an error aborts the run.
+	HandleError func(call ToolCall, err error) (string, error)
+}
+
+// ToolRunResult is the result of a ToolRunner run.
+type ToolRunResult struct {
+	// Response is the last chat completion.
+	Response ChatCompletionResponse
+	// Messages are the messages of the request followed by the messages of the assistant and the tools.
+	Messages []ChatCompletionMessage
+	// Usage is the sum of the usage of all the chat completions.
+	Usage Usage
+	// Iterations is the number of chat completions.
+	Iterations int
+}
+
+// Run sends the request and executes the tool calls of the responses, appending the messages of the
+// assistant and of the tools to the conversation, until the model finishes without calling a tool.
+// The tool calls of a response are executed concurrently, a panicking handler fails its call with
+// ErrToolPanicked. When the request has no tools, the tools of the registry are sent. Responses
+// calling a function with the legacy FunctionCall field fail with ErrToolRunnerFunctionCall, as
+// only tool calls are executed.
+func (r *ToolRunner) Run(ctx context.Context, request ChatCompletionRequest) (result ToolRunResult, err error) {
+	if request.N > 1 {
+		err = ErrToolRunnerMultipleChoices
+		return
+	}
+	if len(request.Tools) == 0 {
+		request.Tools = r.Registry.Tools()
+	}
+
+	maxIterations := r.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = defaultToolRunnerMaxIterations
+	}
+
+	result.Messages = append([]ChatCompletionMessage(nil), request.Messages...)
+	for result.Iterations < maxIterations {
+		request.Messages = result.Messages
+		result.Response, err = r.Client.CreateChatCompletion(ctx, request)
+		if err != nil {
+			return
+		}
+		result.Iterations++
+		result.Usage.PromptTokens += result.Response.Usage.PromptTokens
+		result.Usage.CompletionTokens += result.Response.Usage.CompletionTokens
+		result.Usage.TotalTokens += result.Response.Usage.TotalTokens
+
+		if len(result.Response.Choices) == 0 {
+			err = ErrToolRunnerNoChoices
+			return
+		}
+
+		message := result.Response.Choices[0].Message
+		result.Messages = append(result.Messages, message)
+		if len(message.ToolCalls) == 0 {
+			if message.FunctionCall != nil {
+				err = fmt.Errorf("%w: %s", ErrToolRunnerFunctionCall, message.FunctionCall.Name)
+			}
+			return
+		}
+
+		var toolMessages []ChatCompletionMessage
+		toolMessages, err = r.callTools(ctx, message.ToolCalls)
+		if err != nil {
+			return
+		}
+		result.Messages = append(result.Messages, toolMessages...)
+	}
+
+	err = fmt.Errorf("%w: %d", ErrToolRunnerMaxIterations, maxIterations)
+	return
+}
+
+// callTools executes the tool calls concurrently and returns the tool messages in the order of the calls.
+func (r *ToolRunner) callTools(ctx context.Context, calls []ToolCall) ([]ChatCompletionMessage, error) {
+	messages := make([]ChatCompletionMessage, len(calls))
+	errs := make([]error, len(calls))
+
+	wg := sync.WaitGroup{}
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call ToolCall) {
+			defer wg.Done()
+
+			content, err := r.callTool(ctx, call)
+			if err != nil {
+				content, err = r.handleError(call, err)
+			}
+			messages[i] = ChatCompletionMessage{
+				Role:       ChatMessageRoleTool,
+				Content:    content,
+				Name:       call.Function.Name,
+				ToolCallID: call.ID,
+			}
+			errs[i] = err
+		}(i, call)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// callTool executes the tool call, a panic of the handler fails the call instead of the process.
+func (r *ToolRunner) callTool(ctx context.Context, call ToolCall) (content string, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrToolPanicked, call.Function.Name, p)
+		}
+	}()
+	return r.Registry.Call(ctx, call)
+}
+
+func (r *ToolRunner) handleError(call ToolCall, err error) (string, error) {
+	if r.HandleError != nil {
+		return r.HandleError(call, err)
+	}
+	return "Error: " + err.Error(), nil
+}
@@ -0,0 +1,280 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/internal/test/checks"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+type weatherArguments struct {
+	Location string `json:"location"`
+	Unit     string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+}
+
+type weatherResult struct {
+	Temperature int    `json:"temperature"`
+	Unit        string `json:"unit"`
+}
+
+var weatherFunction = openai.FunctionDefinition{
+	Name:        "get_current_weather",
+	Description: "Get the current weather in a given location",
+	Parameters: jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"location": {Type: jsonschema.String},
+			"unit":     {Type: jsonschema.String, Enum: []string{"celsius", "fahrenheit"}},
+		},
+		Required: []string{"location"},
+	},
+}
+
+func TestToolRegistry(t *testing.T) {
+	registry := openai.NewToolRegistry()
+	err := openai.RegisterTool(registry, weatherFunction,
+		func(_ context.Context, args weatherArguments) (weatherResult, error) {
+			if args.Location == "" {
+				return weatherResult{}, errors.New("location is required")
+			}
+			return weatherResult{Temperature: 22, Unit: "celsius"}, nil
+		})
+	checks.NoError(t, err, "RegisterTool error")
+	registry.Register(openai.FunctionDefinition{Name: "get_time"}, func(context.Context, string) (string, error) {
+		return "12:00", nil
+	})
+
+	tools := registry.Tools()
+	if len(tools) != 2 || tools[0].Function.Name != weatherFunction.Name || tools[1].Type != openai.ToolTypeFunction {
+		t.Fatalf("unexpected tools: %+v", tools)
+	}
+
+	ctx := context.Background()
+	call := func(name, arguments string) (string, error) {
+		return registry.Call(ctx, openai.ToolCall{Function: openai.FunctionCall{Name: name, Arguments: arguments}})
+	}
+
+	result, err := call(weatherFunction.Name, `{"location": "Boston, MA"}`)
+	checks.NoError(t, err, "Call error")
+	if result != `{"temperature":22,"unit":"celsius"}` {
+		t.Fatalf("Call() = %s", result)
+	}
+
+	result, err = call("get_time", "")
+	checks.NoError(t, err, "Call error")
+	if result != "12:00" {
+		t.Fatalf("Call() = %s, want 12:00", result)
+	}
+
+	_, err = call(weatherFunction.Name, `{"location": 1}`)
+	checks.ErrorContains(t, err, "invalid arguments", "Call should fail to decode invalid arguments")
+
+	_, err = call(weatherFunction.Name, `{}`)
+	checks.ErrorContains(t, err, "location is required", "Call should return the error of the handler")
+
+	_, err = call("unknown", `{}`)
+	checks.ErrorIs(t, err, openai.ErrToolNotFound, "Call should fail for unknown tools")
+}
+
+// handleToolCallsEndpoint calls both tools of the request until it receives their results.
+func handleToolCallsEndpoint(
+	t *testing.T,
+	requests *[]openai.ChatCompletionRequest,
+) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request openai.ChatCompletionRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		checks.NoError(t, err, "Decode error")
+		*requests = append(*requests, request)
+
+		message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant}
+		finishReason := openai.FinishReasonStop
+		last := request.Messages[len(request.Messages)-1]
+		if last.Role == openai.ChatMessageRoleTool {
+			var contents []string
+			for _, m := range request.Messages {
+				if m.Role == openai.ChatMessageRoleTool {
+					contents = append(contents, m.ToolCallID+"="+m.Content)
+				}
+			}
+			message.Content = strings.Join(contents, ";")
+		} else {
+			finishReason = openai.FinishReasonToolCalls
+			for _, tool := range request.Tools {
+				message.ToolCalls = append(message.ToolCalls, openai.ToolCall{
+					ID:       "call_" + tool.Function.Name,
+					Type:     openai.ToolTypeFunction,
+					Function: openai.FunctionCall{Name: tool.Function.Name, Arguments: `{"location": "Boston"}`},
+				})
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: message, FinishReason: finishReason}},
+			Usage:   openai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		})
+	}
+}
+
+func TestToolRunner(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var requests []openai.ChatCompletionRequest
+	server.RegisterHandler("/v1/chat/completions", handleToolCallsEndpoint(t, &requests))
+
+	// both tools wait for each other, which only succeeds when they are called concurrently
+	started := sync.WaitGroup{}
+	started.Add(2)
+	registry := openai.NewToolRegistry()
+	err := openai.RegisterTool(registry, openai.FunctionDefinition{Name: weatherFunction.Name},
+		func(context.Context, weatherArguments) (weatherResult, error) {
+			started.Done()
+			started.Wait()
+			return weatherResult{Temperature: 22, Unit: "celsius"}, nil
+		})
+	checks.NoError(t, err, "RegisterTool error")
+	registry.Register(openai.FunctionDefinition{Name: "get_time"}, func(context.Context, string) (string, error) {
+		started.Done()
+		started.Wait()
+		return "", errors.New("clock is broken")
+	})
+
+	runner := &openai.ToolRunner{Client: client, Registry: registry}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := runner.Run(ctx, openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Weather in Boston?"}},
+	})
+	checks.NoError(t, err, "Run error")
+
+	if result.Iterations != 2 || len(requests) != 2 || result.Usage.TotalTokens != 30 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(requests[0].Tools) != 2 {
+		t.Fatalf("the tools of the registry weren't sent: %+v", requests[0].Tools)
+	}
+	parameters, err := json.Marshal(requests[0].Tools[0].Function.Parameters)
+	checks.NoError(t, err, "Marshal error")
+	//nolint:lll
+	wantParameters := `{"properties":{"location":{"properties":{},"type":"string"},"unit":{"enum":["celsius","fahrenheit"],"properties":{},"type":"string"}},"required":["location"],"type":"object"}`
+	if string(parameters) != wantParameters {
+		t.Fatalf("parameters weren't generated from the arguments: %s", parameters)
+	}
+
+	roles := messageRoles(result.Messages)
+	if roles != "user,assistant,tool,tool,assistant" {
+		t.Fatalf("unexpected messages: %s", roles)
+	}
+	want := `call_get_current_weather={"temperature":22,"unit":"celsius"};call_get_time=Error: clock is broken`
+	if content := result.Messages[4].Content; content != want {
+		t.Fatalf("unexpected answer: %s, want %s", content, want)
+	}
+}
+
+func TestToolRunnerErrors(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var requests []openai.ChatCompletionRequest
+	server.RegisterHandler("/v1/chat/completions", handleToolCallsEndpoint(t, &requests))
+
+	registry := openai.NewToolRegistry()
+	registry.Register(weatherFunction, func(context.Context, string) (string, error) {
+		return "", errors.New("service unavailable")
+	})
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Weather in Boston?"}},
+	}
+
+	runner := &openai.ToolRunner{
+		Client:   client,
+		Registry: registry,
+		HandleError: func(_ openai.ToolCall, err error) (string, error) {
+			return "", err
+		},
+	}
+	_, err := runner.Run(context.Background(), request)
+	checks.ErrorContains(t, err, "service unavailable", "Run should return the error of HandleError")
+
+	runner = &openai.ToolRunner{Client: client, Registry: registry, MaxIterations: 1}
+	result, err := runner.Run(context.Background(), request)
+	checks.ErrorIs(t, err, openai.ErrToolRunnerMaxIterations, "Run should stop after MaxIterations")
+	if result.Iterations != 1 {
+		t.Fatalf("Run() iterations = %d, want 1", result.Iterations)
+	}
+
+	request.N = 2
+	_, err = runner.Run(context.Background(), request)
+	checks.ErrorIs(t, err, openai.ErrToolRunnerMultipleChoices, "Run should fail for N > 1")
+}
+
+func TestToolRunnerPanickingTool(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	var requests []openai.ChatCompletionRequest
+	server.RegisterHandler("/v1/chat/completions", handleToolCallsEndpoint(t, &requests))
+
+	registry := openai.NewToolRegistry()
+	registry.Register(weatherFunction, func(context.Context, string) (string, error) {
+		return "sunny", nil
+	})
+	registry.Register(openai.FunctionDefinition{Name: "get_time"}, func(context.Context, string) (string, error) {
+		panic("clock is broken")
+	})
+	request := openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Weather in Boston?"}},
+	}
+
+	// the panic is reported to the model as the error of the call
+	runner := &openai.ToolRunner{Client: client, Registry: registry}
+	result, err := runner.Run(context.Background(), request)
+	checks.NoError(t, err, "Run error")
+	want := "call_get_current_weather=sunny;call_get_time=Error: tool handler panicked: get_time: clock is broken"
+	if content := result.Messages[len(result.Messages)-1].Content; content != want {
+		t.Fatalf("unexpected answer: %s, want %s", content, want)
+	}
+
+	runner.HandleError = func(_ openai.ToolCall, err error) (string, error) {
+		return "", err
+	}
+	_, err = runner.Run(context.Background(), request)
+	checks.ErrorIs(t, err, openai.ErrToolPanicked, "Run should return the panic of the tool")
+}
+
+func TestToolRunnerLegacyFunctionCall(t *testing.T) {
+	client, server, teardown := setupOpenAITestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{
+					Role:         openai.ChatMessageRoleAssistant,
+					FunctionCall: &openai.FunctionCall{Name: weatherFunction.Name, Arguments: `{"location": "Boston"}`},
+				},
+				FinishReason: openai.FinishReasonFunctionCall,
+			}},
+		})
+	})
+
+	runner := &openai.ToolRunner{Client: client, Registry: openai.NewToolRegistry()}
+	result, err := runner.Run(context.Background(), openai.ChatCompletionRequest{
+		Model:    openai.GPT4,
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Weather in Boston?"}},
+	})
+	checks.ErrorIs(t, err, openai.ErrToolRunnerFunctionCall, "Run should fail for legacy function calls")
+	if result.Iterations != 1 || len(result.Messages) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}